@@ -3,15 +3,19 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/FollowTheProcess/msg"
-	"github.com/FollowTheProcess/venv/pkg/flit"
+	"github.com/FollowTheProcess/venv/pkg/environment"
 	"github.com/FollowTheProcess/venv/pkg/poetry"
+	"github.com/FollowTheProcess/venv/pkg/project"
+	"github.com/FollowTheProcess/venv/pkg/pyproject"
 	"github.com/FollowTheProcess/venv/pkg/python"
+	"github.com/FollowTheProcess/venv/pkg/tools"
 	"github.com/fatih/color"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/afero"
@@ -24,13 +28,8 @@ var (
 
 const (
 	debugEnv        = "VENV_DEBUG"
-	reqTxt          = "requirements.txt"
-	reqDev          = "requirements-dev.txt"
-	pyProjectTOML   = "pyproject.toml"
 	venvDir         = "venv"
-	dotVenvDir      = ".venv"
-	setupCFG        = "setup.cfg"
-	setupPy         = "setup.py"
+	managedEnv      = ".venv" // The environment venv builds, and the only one it will sync
 	createNewOption = "Create a new Environment"
 	abortOption     = "Abort"
 	helpText        = `
@@ -62,14 +61,49 @@ Examples:
 # Let venv work everything out
 $ venv
 
+# Bring an existing environment up to date after pulling changes
+$ venv --sync
+
+# Fail if the environment needs syncing, e.g. in CI or a git hook
+$ venv --check
+
+# Create environments for every project in a monorepo
+$ venv --all
+
 Flags:
-  -h, --help      Help for venv
-  -v, --version   Show venv's version info
-  -c, --create    Bypass interactive prompt, telling it to create a new virtual environment
-  -a, --abort     Bypass interactive prompt, telling it to abort and exit
+  -h, --help             Help for venv
+  -v, --version          Show venv's version info
+  -c, --create           Bypass interactive prompt, telling it to create a new virtual environment
+  -a, --abort            Bypass interactive prompt, telling it to abort and exit
+  -s, --sync             Re-install the project's dependencies into an existing environment
+      --check            Exit non-zero if the environment is broken or out of date, changing nothing
+      --recreate         Bypass interactive prompt, recreating a broken environment and reinstalling
+      --install-tools    Bypass interactive prompt, installing any tools the project needs (e.g. poetry) if missing
+      --extras           Comma separated list of extras to install, overriding the auto-detected ones
+      --backend          Tool used to create environments and install packages: auto, pip or uv (default auto)
+      --all              Create environments for every project in the directory tree (workspace mode)
+      --requirements     Comma separated glob patterns of requirements files to install e.g. "deps/*.txt"
+
+Poetry Flags:
+      --with             Comma separated optional dependency groups to install as well
+      --without          Comma separated dependency groups to leave out
+      --all-extras       Install every extra (--extras chooses specific ones)
+      --poetry-sync      Remove anything from the environment that isn't in poetry.lock
+      --no-root          Install only the dependencies, not the project itself
+      --poetry-lock      Run 'poetry lock' without asking if poetry.lock is out of date
 
 Environment Variables:
-  VENV_DEBUG   If set to anything will print debug information to stderr`
+  VENV_DEBUG             If set to anything will print debug information to stderr
+  VENV_EXTRAS            Default value for --extras
+  VENV_BACKEND           Default value for --backend
+  VENV_REQUIREMENTS      Default value for --requirements
+  VENV_INSTALL_TOOLS     If set to anything, the same as --install-tools
+  VENV_POETRY_WITH       Default value for --with
+  VENV_POETRY_WITHOUT    Default value for --without
+  VENV_POETRY_ALL_EXTRAS If set to anything, the same as --all-extras
+  VENV_POETRY_SYNC       If set to anything, the same as --poetry-sync
+  VENV_POETRY_NO_ROOT    If set to anything, the same as --no-root
+  VENV_POETRY_LOCK       If set to anything, the same as --poetry-lock`
 )
 
 // App represents the venv CLI program
@@ -81,6 +115,23 @@ type App struct {
 	fs      afero.Afero    // A filesystem, so we can mock out during tests
 }
 
+// Options are the user's choices, from command line flags or environment variables,
+// that change how venv behaves
+type Options struct {
+	Backend      string         // The python backend to use, one of "auto", "pip" or "uv"
+	Extras       []string       // Extras to install instead of the auto-detected development ones
+	Requirements []string       // Extra glob patterns for requirements files, every file matched is installed
+	All          bool           // Workspace mode, build environments for every project under cwd
+	Create       bool           // Bypass the interactive prompt, creating a new environment
+	Recreate     bool           // Bypass the interactive prompt, recreating a broken environment
+	Sync         bool           // Re-install the project's dependencies into an existing environment
+	Check        bool           // Report whether the environment is up to date without changing anything
+	Abort        bool           // Bypass the interactive prompt, aborting
+	InstallTools bool           // Install tools the project needs (e.g. poetry) without asking if they're missing
+	Poetry       poetry.Options // Choices for poetry install, the extras come from Extras
+	PoetryLock   bool           // Update a stale poetry.lock without asking
+}
+
 // New creates and returns a new App configured with the filesystem, logger
 // and printers
 func New(stdout, stderr io.Writer, fs afero.Fs, printer *msg.Printer) *App {
@@ -122,185 +173,324 @@ func (a *App) Version() {
 
 // Run is the entry point to the CLI, this is what gets run when
 // you call `venv` on the terminal
-func (a *App) Run(create, abort bool) error { // nolint: gocyclo
-	// gocyclo moans because of too many switches but realistically this is the easiest
-	// way of handling it and ensuring only one logical branch is executed
+func (a *App) Run(options Options) error {
+	err := a.run(options)
+
+	// Show the user exactly where their pyproject.toml is broken before aborting
+	var parseErr *pyproject.ParseError
+	if errors.As(err, &parseErr) {
+		a.showParseError(parseErr)
+	}
+
+	return err
+}
+
+// showParseError prints the offending lines of a broken pyproject.toml and
+// any hint on how to fix it, the error itself is left for the caller to report
+func (a *App) showParseError(err *pyproject.ParseError) {
+	if err.Snippet != "" {
+		fmt.Fprintf(a.stderr, "\n%s", err.Snippet)
+	}
+	if err.Hint != "" {
+		fmt.Fprintf(a.stderr, "\n%s %s\n", color.YellowString("hint:"), err.Hint)
+	}
+	fmt.Fprintln(a.stderr)
+}
+
+// run does the actual work of Run
+func (a *App) run(options Options) error {
 	cwd, err := os.Getwd()
 	if err != nil {
 		return fmt.Errorf("could not get cwd: %w", err)
 	}
 
-	if create && abort {
+	if err := validate(options); err != nil {
+		return err
+	}
+
+	// Make any tools venv has installed in the past available to the plans, and
+	// to backend selection so a uv venv installed itself is picked up
+	if err := tools.Activate(); err != nil {
+		a.logger.WithField("error", err).Debugln("could not activate venv's tools")
+	}
+
+	backend, err := python.SelectBackend(options.Backend)
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+	python.Use(backend)
+	a.logger.WithField("backend", backend.Name()).Debugln("selected python backend")
+
+	registry := newRegistry(options)
+
+	if options.All {
+		return a.runAll(cwd, registry, options)
+	}
+
+	root, py, err := a.findRoot(cwd, registry)
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+	if root != cwd {
+		a.logger.WithField("root", root).Debugln("project root found above cwd")
+		a.printer.Infof("Using project root: %q", root)
+		cwd = root
+	}
+
+	// From here on, everything happens relative to the project root
+	fs := a.dirFs(cwd)
+
+	envs, err := environment.Find(fs)
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	if len(envs) == 0 {
+		if options.Check {
+			return errNoEnv
+		}
+		// No environment, so build one
+		return a.install(cwd, fs, py, registry, options)
+	}
+
+	return a.existing(cwd, fs, py, envs, registry, options)
+}
+
+// validate checks for combinations of options that make no sense together
+func validate(options Options) error {
+	switch {
+	case options.Create && options.Abort:
 		// These flags are mutually exclusive
 		return fmt.Errorf("--create and --abort are mutually exclusive")
+	case options.Recreate && options.Abort:
+		return fmt.Errorf("--recreate and --abort are mutually exclusive")
+	case options.Check && options.All:
+		return fmt.Errorf("--check cannot be used with --all")
+	default:
+		return nil
 	}
+}
 
-	switch {
-	case a.cwdHasDir(dotVenvDir):
-		// .venv found in cwd
-		a.logger.WithField("venv directory", dotVenvDir).Debugln("virtual environment directory found")
-		a.printer.Infof("There is already a virtual environment in this directory: %q", dotVenvDir)
-
-	case a.cwdHasDir(venvDir):
-		// venv found in cwd
-		a.logger.WithField("venv directory", venvDir).Debugln("virtual environment directory found")
-		a.printer.Infof("There is already a virtual environment in this directory: %q", venvDir)
-
-	case a.cwdHasFile(reqDev):
-		// requirements_dev.txt found in cwd
-		a.logger.WithField("requirements file", reqDev).Debugln("requirements file found")
-		a.printer.Infof("Found %q. Creating virtual environment and installing requirements", reqDev)
-		if err := python.CreateVenv(cwd, a.stdout, a.stderr); err != nil {
-			return fmt.Errorf("%w", err)
-		}
-		if err := python.UpdateSeeds(cwd, a.stdout, a.stderr); err != nil {
-			return fmt.Errorf("%w", err)
-		}
-		if err := python.InstallRequirements(cwd, a.stdout, a.stderr, reqDev); err != nil {
-			return fmt.Errorf("%w", err)
+// existing handles a project that already has environment(s) 'envs': reporting on
+// them, checking their health and either checking, syncing or leaving them
+// depending on 'options' and what has changed since they were built
+func (a *App) existing(cwd string, fs afero.Afero, py *pyproject.PyProject, envs []environment.Environment, registry *project.Registry, options Options) error {
+	a.report(envs)
+
+	unhealthy, err := a.checkHealth(fs, py, envs)
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+	if options.Check {
+		return a.check(fs, envs, unhealthy, options.Requirements)
+	}
+
+	// Only the environment venv manages is ours to repair, any others are
+	// reported and otherwise carried on past
+	for _, env := range unhealthy {
+		if env.Path != managedEnv {
+			a.printer.Warnf("venv only recreates %q, leaving %q for you to fix", managedEnv, env.Path)
 		}
+	}
+	if hasManaged(unhealthy) {
+		return a.repair(cwd, fs, py, unhealthy, registry, options)
+	}
 
-	case a.cwdHasFile(reqTxt):
-		// requirements.txt found in cwd
-		a.logger.WithField("requirements file", reqTxt).Debugln("requirements file found")
-		a.printer.Infof("Found %q. Creating virtual environment and installing requirements", reqTxt)
-		if err := python.CreateVenv(cwd, a.stdout, a.stderr); err != nil {
-			return fmt.Errorf("%w", err)
+	changes, stamped, err := a.changes(fs, envs, options.Requirements)
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	switch {
+	case options.Sync:
+		return a.sync(cwd, fs, py, envs, registry, options)
+	case stamped && len(changes) == 0:
+		a.printer.Good("Environment up to date")
+		return nil
+	case stamped:
+		a.printer.Info("Dependencies have changed since the environment was built:")
+		for _, change := range changes {
+			a.printer.Textf("  - %s", change)
 		}
-		if err := python.UpdateSeeds(cwd, a.stdout, a.stderr); err != nil {
-			return fmt.Errorf("%w", err)
+		return a.sync(cwd, fs, py, envs, registry, options)
+	default:
+		// venv can't sync environments it doesn't manage, so don't suggest it
+		if hasManaged(envs) {
+			a.printer.Textf("Run 'venv --sync' to update it with the project's dependencies")
 		}
-		if err := python.InstallRequirements(cwd, a.stdout, a.stderr, reqTxt); err != nil {
-			return fmt.Errorf("%w", err)
+		a.printer.Good("Done")
+		return nil
+	}
+}
+
+// report tells the user about each of the existing environments 'envs'
+func (a *App) report(envs []environment.Environment) {
+	for _, env := range envs {
+		a.logger.WithFields(logrus.Fields{
+			"path":    env.Path,
+			"kind":    env.Kind,
+			"version": env.Version,
+		}).Debugln("virtual environment found")
+		version := env.Version
+		if version == "" {
+			version = "unknown"
 		}
+		a.printer.Infof("There is already a %s environment in this directory: %q (python %s)", env.Kind, env.Path, version)
+	}
+}
 
-	case a.cwdHasFile(pyProjectTOML):
-		// pyproject.toml found in cwd
-		a.logger.Debugln(fmt.Sprintf("%s found", pyProjectTOML))
-		switch {
-		case a.cwdHasFile(setupCFG):
-			// pyproject.toml + setup.cfg found in cwd
-			a.logger.WithField("setuptools file", setupCFG).Debugln("found setuptools file")
-			a.printer.Infof("Found %q with %q. Creating virtual environment and installing dependencies (setuptools)", pyProjectTOML, setupCFG)
-			// Make a venv and install -e .[dev]
-			// If the project does not define [dev] extras, pip will automatically fall back to -e . for us
-			if err := python.CreateVenv(cwd, a.stdout, a.stderr); err != nil {
-				return fmt.Errorf("%w", err)
-			}
-			if err := python.UpdateSeeds(cwd, a.stdout, a.stderr); err != nil {
-				return fmt.Errorf("%w", err)
-			}
-			if err := python.Install(cwd, a.stdout, a.stderr, []string{"-e", ".[dev]"}); err != nil {
-				return fmt.Errorf("%w", err)
-			}
+// sync re-runs the detected install strategy against the existing environment
+// so it picks up any changes to the project's dependencies
+func (a *App) sync(cwd string, fs afero.Afero, py *pyproject.PyProject, envs []environment.Environment, registry *project.Registry, options Options) error {
+	if !hasManaged(envs) {
+		a.printer.Warnf("venv can only sync the environment in %q, leaving %q alone", managedEnv, envs[0].Path)
+		return nil
+	}
 
-		case a.cwdHasFile(setupPy):
-			// pyproject.toml + setup.py found in cwd
-			a.logger.WithField("setuptools file", setupPy).Debugln("found setuptools file")
-			a.printer.Infof("Found %q with %q. Creating virtual environment and installing dependencies (setuptools)", pyProjectTOML, setupPy)
-			// Since parsing a python file to determine if it has a .[dev] might be tricky
-			// just do a normal -e .
-			if err := python.CreateVenv(cwd, a.stdout, a.stderr); err != nil {
-				return fmt.Errorf("%w", err)
-			}
-			if err := python.UpdateSeeds(cwd, a.stdout, a.stderr); err != nil {
-				return fmt.Errorf("%w", err)
-			}
-			if err := python.Install(cwd, a.stdout, a.stderr, []string{"-e", "."}); err != nil {
-				return fmt.Errorf("%w", err)
-			}
+	match, err := registry.DetectWith(fs, py)
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
 
-		default:
-			// pyproject.toml found on it's own in cwd
-			a.logger.Debugln("project not setuptools based")
-			a.logger.Debugln("checking whether it's poetry or flit")
-			// Parse pyproject.toml to determine poetry or flit and make the call
-			poetryFile, err := poetry.IsPoetryFile(a.fs, pyProjectTOML)
-			if err != nil {
-				return fmt.Errorf("%w", err)
-			}
-			flitFile, err := flit.IsFlitFile(a.fs, pyProjectTOML)
-			if err != nil {
-				return fmt.Errorf("%w", err)
-			}
+	if !match.Found() {
+		a.logger.Debugln("cannot detect environment for project")
+		a.printer.Warn("Cannot auto-detect project environment, nothing to sync")
+		return nil
+	}
 
-			switch {
-			case poetryFile:
-				// pyproject.toml is a poetry spec
-				a.logger.WithField("file", pyProjectTOML).Debugln("project file specifies poetry")
-				a.printer.Infof("Found %q specifying poetry. Installing...", pyProjectTOML)
-				if err := poetry.Install(cwd, a.stdout, a.stderr); err != nil {
-					return fmt.Errorf("%w", err)
-				}
-
-			case flitFile:
-				// pyproject.toml is a flit spec
-				a.logger.WithField("file", pyProjectTOML).Debugln("project file specifies flit")
-				a.printer.Infof("Found %q specifying flit. Installing...", pyProjectTOML)
-				if err := flit.Install(cwd, a.stdout, a.stderr); err != nil {
-					return fmt.Errorf("%w", err)
-				}
-			}
+	a.logger.WithFields(logrus.Fields{
+		"detector":   match.Detector,
+		"confidence": match.Confidence,
+	}).Debugln("project detected")
+
+	for _, warning := range match.Warnings {
+		a.printer.Warn(warning)
+	}
+
+	a.printer.Infof("Syncing %q with the project's dependencies (%s)", managedEnv, match.Detector)
+	if err := a.ensureTools(match.Plan.Tools, options); err != nil {
+		return fmt.Errorf("%w", err)
+	}
+	if err := match.Plan.Sync(cwd, a.stdout, a.stderr); err != nil {
+		return fmt.Errorf("%w", err)
+	}
+	a.writeStamp(fs, options.Requirements)
+
+	a.printer.Good("Done")
+	return nil
+}
+
+// hasManaged reports whether one of 'envs' is the environment venv builds
+func hasManaged(envs []environment.Environment) bool {
+	for _, env := range envs {
+		if env.Path == managedEnv {
+			return true
 		}
+	}
 
-	default:
+	return false
+}
+
+// install asks the registered detectors what kind of project is in cwd and builds
+// it's environment, falling back to the --create/--abort flags or the interactive
+// prompt if the project isn't recognised
+func (a *App) install(cwd string, fs afero.Afero, py *pyproject.PyProject, registry *project.Registry, options Options) error {
+	match, err := registry.DetectWith(fs, py)
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	if !match.Found() {
 		a.logger.Debugln("cannot detect environment for project")
 		a.printer.Warn("Cannot auto-detect project environment")
 		// User called `venv` so must want something doing
 		// check create or abort flags or prompt for what to do next
+		return a.undetected(cwd, options.Create, options.Abort)
+	}
 
-		switch {
-		case abort:
-			// User passed --abort
-			a.printer.Fail("Aborting!")
-			return nil
+	a.logger.WithFields(logrus.Fields{
+		"detector":   match.Detector,
+		"confidence": match.Confidence,
+	}).Debugln("project detected")
+	for _, reason := range match.Reasons {
+		a.logger.WithField("detector", match.Detector).Debugln(reason)
+	}
 
-		case create:
-			// User passed --create
-			a.printer.Info("Creating a new python virtual environment")
-			if err := python.CreateVenv(cwd, a.stdout, a.stderr); err != nil {
-				return fmt.Errorf("%w", err)
-			}
-			if err := python.UpdateSeeds(cwd, a.stdout, a.stderr); err != nil {
-				return fmt.Errorf("%w", err)
-			}
+	for _, warning := range match.Warnings {
+		a.printer.Warn(warning)
+	}
 
-		default:
-			// User didn't pass the flags so prompt for what to do next
-			next := ""
-			prompt := &survey.Select{
-				Message: "What's next?",
-				Options: []string{createNewOption, abortOption},
-			}
-			if err := survey.AskOne(prompt, &next); err != nil {
-				return fmt.Errorf("could not generate prompt: %w", err)
-			}
+	a.printer.Info(match.Plan.Summary)
+	if err := a.ensureTools(match.Plan.Tools, options); err != nil {
+		return fmt.Errorf("%w", err)
+	}
+	if err := match.Plan.Execute(cwd, a.stdout, a.stderr); err != nil {
+		return fmt.Errorf("%w", err)
+	}
+	a.writeStamp(fs, options.Requirements)
 
-			switch next {
-			case createNewOption:
-				a.printer.Info("Creating a new python virtual environment")
-				if err := python.CreateVenv(cwd, a.stdout, a.stderr); err != nil {
-					return fmt.Errorf("%w", err)
-				}
-				if err := python.UpdateSeeds(cwd, a.stdout, a.stderr); err != nil {
-					return fmt.Errorf("%w", err)
-				}
-
-			case abortOption:
-				a.printer.Fail("Aborting!")
-				return nil
-
-			default:
-				// This should never happen
-				return fmt.Errorf("somehow entered an unrecognised option in prompt: %s", next)
-			}
+	// We'll only get here if the plan was successful
+	// so return nil and a Done marker
+	a.printer.Good("Done")
+	return nil
+}
+
+// undetected handles the case where no detector recognised the project, either
+// obeying the --create/--abort flags or asking the user what to do next
+func (a *App) undetected(cwd string, create, abort bool) error {
+	switch {
+	case abort:
+		// User passed --abort
+		a.printer.Fail("Aborting!")
+		return nil
+
+	case create:
+		// User passed --create
+		if err := a.createEmpty(cwd); err != nil {
+			return fmt.Errorf("%w", err)
+		}
 
+	default:
+		// User didn't pass the flags so prompt for what to do next
+		next := ""
+		prompt := &survey.Select{
+			Message: "What's next?",
+			Options: []string{createNewOption, abortOption},
+		}
+		if err := survey.AskOne(prompt, &next); err != nil {
+			return fmt.Errorf("could not generate prompt: %w", err)
 		}
 
+		switch next {
+		case createNewOption:
+			if err := a.createEmpty(cwd); err != nil {
+				return fmt.Errorf("%w", err)
+			}
+
+		case abortOption:
+			a.printer.Fail("Aborting!")
+			return nil
+
+		default:
+			// This should never happen
+			return fmt.Errorf("somehow entered an unrecognised option in prompt: %s", next)
+		}
 	}
 
-	// We'll only get here if whatever logical branch was run was successful
-	// so return nil and a Done marker
 	a.printer.Good("Done")
 	return nil
 }
+
+// createEmpty creates a new virtual environment in cwd with nothing but
+// up to date seed packages installed
+func (a *App) createEmpty(cwd string) error {
+	a.printer.Info("Creating a new python virtual environment")
+	if err := python.CreateVenv(cwd, a.stdout, a.stderr); err != nil {
+		return fmt.Errorf("%w", err)
+	}
+	if err := python.UpdateSeeds(cwd, a.stdout, a.stderr); err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	return nil
+}
@@ -3,13 +3,22 @@ package cli
 import (
 	"bytes"
 	"fmt"
+	"io"
+	"reflect"
 	"strings"
 	"testing"
 
 	"github.com/FollowTheProcess/msg"
+	"github.com/FollowTheProcess/venv/pkg/environment"
+	"github.com/FollowTheProcess/venv/pkg/project"
+	"github.com/FollowTheProcess/venv/pkg/pyproject"
 	"github.com/spf13/afero"
 )
 
+func newTestPrinter(stdout io.Writer) *msg.Printer {
+	return &msg.Printer{Out: stdout}
+}
+
 func TestApp_Help(t *testing.T) {
 	stdout := &bytes.Buffer{}
 	stderr := &bytes.Buffer{}
@@ -43,3 +52,70 @@ func TestApp_Version(t *testing.T) {
 		t.Errorf("version string did not contain commit: %s", stdout.String())
 	}
 }
+
+func TestApp_sync(t *testing.T) {
+	var ran []string
+	record := func(name string) project.StepFunc {
+		return func(cwd string, stdout, stderr io.Writer) error {
+			ran = append(ran, name)
+			return nil
+		}
+	}
+
+	registry := project.NewRegistry()
+	registry.Register(1, planDetector{
+		plan: project.Plan{
+			Steps: []project.Step{
+				{Description: "create", Run: record("create"), Phase: project.Build},
+				{Description: "install", Run: record("install")},
+			},
+		},
+	})
+
+	tests := []struct {
+		name string
+		envs []environment.Environment
+		want []string
+	}{
+		{
+			name: "managed environment",
+			envs: []environment.Environment{{Path: managedEnv, Kind: environment.Venv}},
+			want: []string{"install"},
+		},
+		{
+			name: "unmanaged environment",
+			envs: []environment.Environment{{Path: "env", Kind: environment.Venv}},
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ran = nil
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+			app := New(stdout, stderr, afero.NewMemMapFs(), newTestPrinter(stdout))
+
+			if err := app.sync(".", app.fs, nil, tt.envs, registry, Options{}); err != nil {
+				t.Fatalf("sync returned an error: %v", err)
+			}
+
+			if !reflect.DeepEqual(ran, tt.want) {
+				t.Errorf("ran %v, wanted %v", ran, tt.want)
+			}
+		})
+	}
+}
+
+// planDetector always recognises the project, returning 'plan'
+type planDetector struct {
+	plan project.Plan
+}
+
+func (planDetector) Name() string {
+	return "plan"
+}
+
+func (p planDetector) Detect(fs afero.Afero, py *pyproject.PyProject) (project.Match, error) {
+	return project.Match{Confidence: project.High, Plan: p.plan}, nil
+}
@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/FollowTheProcess/venv/pkg/environment"
+	"github.com/FollowTheProcess/venv/pkg/project"
+	"github.com/FollowTheProcess/venv/pkg/pyproject"
+	"github.com/spf13/afero"
+)
+
+const (
+	recreateOption = "Recreate the environment and reinstall dependencies"
+	leaveOption    = "Leave it as it is"
+)
+
+// checkHealth checks each of the environments in fs against the project's
+// pyproject.toml 'py', reporting on each and returning the unhealthy ones
+func (a *App) checkHealth(fs afero.Afero, py *pyproject.PyProject, envs []environment.Environment) ([]environment.Environment, error) {
+	requires := py.RequiresPython()
+
+	var unhealthy []environment.Environment
+	for _, env := range envs {
+		health, err := environment.Check(fs, a.fs, env, requires)
+		if err != nil {
+			return nil, fmt.Errorf("could not check environment %s: %w", env.Path, err)
+		}
+
+		if health.OK() {
+			a.logger.WithField("path", env.Path).Debugln("environment is healthy")
+			continue
+		}
+
+		a.printer.Warnf("Environment %q is broken:", env.Path)
+		for _, problem := range health.Problems {
+			a.printer.Textf("  - %s", problem)
+		}
+		unhealthy = append(unhealthy, env)
+	}
+
+	return unhealthy, nil
+}
+
+// repair offers to remove the unhealthy environment venv manages and rebuild the
+// project's environment from scratch, --recreate and --abort bypass the prompt and
+// when not run from a terminal, the environment is left alone
+//
+// Environments venv didn't create are never touched, they're the user's to fix
+func (a *App) repair(cwd string, fs afero.Afero, py *pyproject.PyProject, unhealthy []environment.Environment, registry *project.Registry, options Options) error {
+	if !hasManaged(unhealthy) {
+		return nil
+	}
+
+	recreate := options.Recreate
+	if !recreate && !options.Abort && interactive() {
+		next := ""
+		prompt := &survey.Select{
+			Message: "What's next?",
+			Options: []string{recreateOption, leaveOption},
+		}
+		if err := survey.AskOne(prompt, &next); err != nil {
+			return fmt.Errorf("could not generate prompt: %w", err)
+		}
+		recreate = next == recreateOption
+	}
+
+	if !recreate {
+		a.printer.Fail("Leaving broken environment in place, run 'venv --recreate' to rebuild it")
+		return nil
+	}
+
+	a.printer.Infof("Removing %q", managedEnv)
+	if err := fs.RemoveAll(managedEnv); err != nil {
+		return fmt.Errorf("could not remove %s: %w", managedEnv, err)
+	}
+
+	return a.install(cwd, fs, py, registry, options)
+}
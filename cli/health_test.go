@@ -0,0 +1,216 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/FollowTheProcess/venv/pkg/environment"
+	"github.com/FollowTheProcess/venv/pkg/project"
+	"github.com/FollowTheProcess/venv/pkg/pyproject"
+	"github.com/spf13/afero"
+)
+
+func TestApp_checkHealth(t *testing.T) {
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	app := New(stdout, stderr, afero.NewMemMapFs(), newTestPrinter(stdout))
+
+	fs := afero.Afero{Fs: afero.NewMemMapFs()}
+	files := map[string]string{
+		".venv/bin/python": "",
+		"env/pyvenv.cfg":   "version = 3.8.10\n",
+		"env/bin/python":   "",
+	}
+	for file, content := range files {
+		if err := fs.WriteFile(file, []byte(content), 0o755); err != nil {
+			t.Fatalf("could not create file: %v", err)
+		}
+	}
+
+	envs := []environment.Environment{
+		{Path: ".venv", Kind: environment.Venv, Version: "3.10.4"},
+		{Path: "env", Kind: environment.Venv, Version: "3.8.10"},
+	}
+
+	py, err := pyproject.Parse([]byte("[project]\nrequires-python = \">=3.9\"\n"))
+	if err != nil {
+		t.Fatalf("could not parse pyproject.toml: %v", err)
+	}
+
+	unhealthy, err := app.checkHealth(fs, py, envs)
+	if err != nil {
+		t.Fatalf("checkHealth returned an error: %v", err)
+	}
+
+	if len(unhealthy) != 1 || unhealthy[0].Path != "env" {
+		t.Fatalf("got unhealthy environments %#v, wanted just env", unhealthy)
+	}
+
+	want := `python 3.8.10 does not satisfy requires-python ">=3.9"`
+	if got := stdout.String(); !strings.Contains(got, want) {
+		t.Errorf("output %q does not contain %q", got, want)
+	}
+}
+
+func TestApp_repair(t *testing.T) {
+	tests := []struct {
+		name      string
+		unhealthy []environment.Environment
+		options   Options
+		removed   []string // Environments that should have been removed
+		kept      []string // Environments that should have been left alone
+		rebuilt   bool     // Whether the project should have been rebuilt
+	}{
+		{
+			name:      "managed environment is recreated",
+			unhealthy: []environment.Environment{{Path: managedEnv, Kind: environment.Venv}},
+			options:   Options{Recreate: true},
+			removed:   []string{managedEnv},
+			rebuilt:   true,
+		},
+		{
+			name:      "user environment is left alone",
+			unhealthy: []environment.Environment{{Path: "env", Kind: environment.Venv}},
+			options:   Options{Recreate: true},
+			kept:      []string{"env"},
+			rebuilt:   false,
+		},
+		{
+			name: "only the managed environment is recreated",
+			unhealthy: []environment.Environment{
+				{Path: managedEnv, Kind: environment.Venv},
+				{Path: "venv", Kind: environment.Venv},
+			},
+			options: Options{Recreate: true},
+			removed: []string{managedEnv},
+			kept:    []string{"venv"},
+			rebuilt: true,
+		},
+		{
+			name:      "not a terminal leaves it alone",
+			unhealthy: []environment.Environment{{Path: managedEnv, Kind: environment.Venv}},
+			options:   Options{},
+			kept:      []string{managedEnv},
+			rebuilt:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+			app := New(stdout, stderr, afero.NewMemMapFs(), newTestPrinter(stdout))
+
+			interactive = func() bool { return false }
+			defer func() { interactive = isTerminal }()
+
+			for _, env := range tt.unhealthy {
+				if err := app.fs.WriteFile(env.Path+"/pyvenv.cfg", []byte(""), 0o755); err != nil {
+					t.Fatalf("could not create file: %v", err)
+				}
+			}
+
+			rebuilt := false
+			registry := project.NewRegistry()
+			registry.Register(1, planDetector{
+				plan: project.Plan{Steps: []project.Step{{
+					Description: "build",
+					Run: func(cwd string, stdout, stderr io.Writer) error {
+						rebuilt = true
+						return nil
+					},
+				}}},
+			})
+
+			if err := app.repair(".", app.fs, nil, tt.unhealthy, registry, tt.options); err != nil {
+				t.Fatalf("repair returned an error: %v", err)
+			}
+
+			for _, env := range tt.removed {
+				if exists, _ := app.fs.Exists(env); exists {
+					t.Errorf("%s should have been removed", env)
+				}
+			}
+			for _, env := range tt.kept {
+				if exists, _ := app.fs.Exists(env); !exists {
+					t.Errorf("%s should have been left alone", env)
+				}
+			}
+
+			if rebuilt != tt.rebuilt {
+				t.Errorf("rebuilt = %v, wanted %v", rebuilt, tt.rebuilt)
+			}
+		})
+	}
+}
+
+func TestApp_existing_UnmanagedBroken(t *testing.T) {
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	app := New(stdout, stderr, afero.NewMemMapFs(), newTestPrinter(stdout))
+
+	files := map[string]string{
+		".venv/bin/python": "",
+		"env/pyvenv.cfg":   "version = 3.8.10\n",
+		"env/bin/python":   "",
+	}
+	for file, content := range files {
+		if err := app.fs.WriteFile(file, []byte(content), 0o755); err != nil {
+			t.Fatalf("could not create file: %v", err)
+		}
+	}
+
+	py, err := pyproject.Parse([]byte("[project]\nrequires-python = \">=3.9\"\n"))
+	if err != nil {
+		t.Fatalf("could not parse pyproject.toml: %v", err)
+	}
+
+	envs := []environment.Environment{
+		{Path: managedEnv, Kind: environment.Venv, Version: "3.10.4"},
+		{Path: "env", Kind: environment.Venv, Version: "3.8.10"},
+	}
+
+	synced := false
+	registry := project.NewRegistry()
+	registry.Register(1, planDetector{
+		plan: project.Plan{Steps: []project.Step{{
+			Description: "install",
+			Run: func(cwd string, stdout, stderr io.Writer) error {
+				synced = true
+				return nil
+			},
+		}}},
+	})
+
+	// The broken env is the user's, so the healthy .venv should still be synced
+	if err := app.existing(".", app.fs, py, envs, registry, Options{Sync: true}); err != nil {
+		t.Fatalf("existing returned an error: %v", err)
+	}
+
+	if !synced {
+		t.Errorf(".venv was not synced: %s", stdout.String())
+	}
+}
+
+func TestApp_existing_Unmanaged(t *testing.T) {
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	app := New(stdout, stderr, afero.NewMemMapFs(), newTestPrinter(stdout))
+
+	if err := app.fs.WriteFile("venv/bin/python", []byte(""), 0o755); err != nil {
+		t.Fatalf("could not create file: %v", err)
+	}
+
+	envs := []environment.Environment{{Path: "venv", Kind: environment.Venv, Version: "3.10.4"}}
+	if err := app.existing(".", app.fs, nil, envs, project.NewRegistry(), Options{}); err != nil {
+		t.Fatalf("existing returned an error: %v", err)
+	}
+
+	// venv won't sync an environment it doesn't manage, so mustn't suggest it
+	if strings.Contains(stdout.String(), "venv --sync") {
+		t.Errorf("--sync suggested for an unmanaged environment: %s", stdout.String())
+	}
+}
@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/AlecAivazis/survey/v2"
+)
+
+// choosePoetry asks the user which of a poetry project's 'optional' dependency
+// groups and 'extras' to install, it implements poetry.Chooser
+func choosePoetry(optional, extras []string) (groups, chosen []string, err error) {
+	if len(optional) != 0 {
+		prompt := &survey.MultiSelect{
+			Message: "Optional dependency groups to install:",
+			Options: optional,
+		}
+		if err := survey.AskOne(prompt, &groups); err != nil {
+			return nil, nil, fmt.Errorf("could not generate prompt: %w", err)
+		}
+	}
+
+	if len(extras) != 0 {
+		prompt := &survey.MultiSelect{
+			Message: "Extras to install:",
+			Options: extras,
+		}
+		if err := survey.AskOne(prompt, &chosen); err != nil {
+			return nil, nil, fmt.Errorf("could not generate prompt: %w", err)
+		}
+	}
+
+	return groups, chosen, nil
+}
+
+// confirm asks the user a yes or no 'question', defaulting to yes
+func confirm(question string) (bool, error) {
+	yes := false
+	prompt := &survey.Confirm{Message: question, Default: true}
+	if err := survey.AskOne(prompt, &yes); err != nil {
+		return false, fmt.Errorf("could not generate prompt: %w", err)
+	}
+
+	return yes, nil
+}
+
+// interactive is an internal reassignment of isTerminal used for mocking during tests
+var interactive = isTerminal
+
+// isTerminal reports whether venv is being run from a terminal, and so can prompt
+func isTerminal() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
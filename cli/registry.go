@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"github.com/FollowTheProcess/venv/pkg/conda"
+	"github.com/FollowTheProcess/venv/pkg/flit"
+	"github.com/FollowTheProcess/venv/pkg/hatch"
+	"github.com/FollowTheProcess/venv/pkg/pdm"
+	"github.com/FollowTheProcess/venv/pkg/pep517"
+	"github.com/FollowTheProcess/venv/pkg/pipenv"
+	"github.com/FollowTheProcess/venv/pkg/piptools"
+	"github.com/FollowTheProcess/venv/pkg/poetry"
+	"github.com/FollowTheProcess/venv/pkg/project"
+	"github.com/FollowTheProcess/venv/pkg/requirements"
+	"github.com/FollowTheProcess/venv/pkg/setuptools"
+	"github.com/FollowTheProcess/venv/pkg/uv"
+)
+
+// Detector priorities, a detector with a higher priority is consulted first
+// and wins if two detectors are equally confident about a project
+const (
+	priorityPipTools     = 450
+	priorityRequirements = 400
+	priorityUV           = 375
+	priorityPipenv       = 350
+	priorityConda        = 340
+	prioritySetuptools   = 300
+	priorityPoetry       = 200
+	priorityPDM          = 150
+	priorityFlit         = 100
+	priorityHatch        = 90
+	priorityPEP517       = 10 // Fallback for any other build backend, always Low confidence
+)
+
+// newRegistry returns the registry of every project type venv knows about
+//
+// Adding support for a new type of project is a matter of writing a project.Detector
+// for it and registering it here, configured from 'options' if needed
+func newRegistry(options Options) *project.Registry {
+	registry := project.NewRegistry()
+	registry.Register(priorityPipTools, piptools.Detector{})
+	registry.Register(priorityRequirements, requirements.Detector{Patterns: options.Requirements})
+	registry.Register(priorityUV, uv.Detector{})
+	registry.Register(priorityPipenv, pipenv.Detector{})
+	registry.Register(priorityConda, conda.Detector{})
+	registry.Register(prioritySetuptools, setuptools.Detector{Extras: options.Extras})
+	registry.Register(priorityPoetry, poetry.Detector{
+		Options: poetryOptions(options),
+		Choose:  poetryChooser(options),
+		Confirm: poetryConfirm(options),
+		Lock:    options.PoetryLock,
+	})
+	registry.Register(priorityPDM, pdm.Detector{})
+	registry.Register(priorityFlit, flit.Detector{})
+	registry.Register(priorityHatch, hatch.Detector{})
+	registry.Register(priorityPEP517, pep517.Detector{Extras: options.Extras})
+
+	return registry
+}
+
+// poetryOptions returns the choices for poetry install from 'options', the extras
+// requested are shared with the other detectors
+func poetryOptions(options Options) poetry.Options {
+	chosen := options.Poetry
+	chosen.Extras = options.Extras
+	return chosen
+}
+
+// poetryChooser returns the prompt for poetry's optional groups and extras, or nil
+// if venv shouldn't ask: when it isn't run from a terminal, in workspace mode or with --abort
+func poetryChooser(options Options) poetry.Chooser {
+	if options.All || options.Abort || !interactive() {
+		return nil
+	}
+	return choosePoetry
+}
+
+// poetryConfirm returns the prompt asking whether to update a stale poetry.lock,
+// or nil if venv shouldn't ask, in the same situations as poetryChooser
+func poetryConfirm(options Options) func(question string) (bool, error) {
+	if options.All || options.Abort || !interactive() {
+		return nil
+	}
+	return confirm
+}
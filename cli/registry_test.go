@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/FollowTheProcess/venv/pkg/poetry"
+	"github.com/spf13/afero"
+)
+
+func TestNewRegistry(t *testing.T) {
+	registry := newRegistry(Options{})
+
+	var got []string
+	for _, d := range registry.Detectors() {
+		got = append(got, d.Name())
+	}
+
+	want := []string{"pip-tools", "requirements", "uv", "pipenv", "conda", "setuptools", "poetry", "pdm", "flit", "hatch", "pep517"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got detectors %v, wanted %v", got, want)
+	}
+}
+
+func TestPoetryOptions(t *testing.T) {
+	options := Options{
+		Extras: []string{"cli"},
+		Poetry: poetry.Options{With: []string{"docs"}, NoRoot: true},
+		All:    true,
+	}
+
+	want := poetry.Options{With: []string{"docs"}, Extras: []string{"cli"}, NoRoot: true}
+	if got := poetryOptions(options); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, wanted %+v", got, want)
+	}
+
+	if poetryChooser(options) != nil {
+		t.Error("venv should never prompt for poetry options in workspace mode")
+	}
+	if poetryConfirm(options) != nil {
+		t.Error("venv should never prompt to update poetry.lock in workspace mode")
+	}
+}
+
+func TestNewRegistry_SharedExtras(t *testing.T) {
+	fs := afero.Afero{Fs: afero.NewMemMapFs()}
+	files := map[string]string{
+		"requirements.txt": "",
+		"pyproject.toml":   "[tool.poetry]\nname = \"demo\"\n",
+	}
+	for file, content := range files {
+		if err := fs.WriteFile(file, []byte(content), 0o755); err != nil {
+			t.Fatalf("could not create file: %v", err)
+		}
+	}
+
+	// An extra poetry doesn't know about must not stop another detector winning
+	match, err := newRegistry(Options{Extras: []string{"dev"}}).Detect(fs)
+	if err != nil {
+		t.Fatalf("Detect returned an error: %v", err)
+	}
+
+	if match.Detector != "requirements" {
+		t.Errorf("got detector %q, wanted %q", match.Detector, "requirements")
+	}
+}
@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/FollowTheProcess/venv/pkg/environment"
+	"github.com/FollowTheProcess/venv/pkg/project"
+	"github.com/FollowTheProcess/venv/pkg/pyproject"
+)
+
+// gitDir marks the top of a repository, we never search above it
+const gitDir = ".git"
+
+// userHomeDir is an internal reassignment of os.UserHomeDir used for mocking during tests
+var userHomeDir = os.UserHomeDir
+
+// findRoot walks up the directory tree from 'dir' looking for the root of the project
+// i.e. the first directory containing an existing environment or a project any of the
+// detectors in 'registry' recognise
+//
+// The search stops at the top of a git repository, the user's home directory or the
+// filesystem root. If no project is found, 'dir' itself is returned
+//
+// The root's parsed pyproject.toml is returned alongside it so callers don't have to
+// parse it again, it's nil if the root doesn't have one
+func (a *App) findRoot(dir string, registry *project.Registry) (string, *pyproject.PyProject, error) {
+	home, err := userHomeDir()
+	if err != nil {
+		// Not fatal, we just can't stop there
+		home = ""
+	}
+
+	var start *pyproject.PyProject
+	current := dir
+	for {
+		a.logger.WithField("directory", current).Debugln("Looking for project root")
+		fs := a.dirFs(current)
+
+		py, err := pyproject.Read(fs)
+		if err != nil {
+			return "", nil, fmt.Errorf("%w", err)
+		}
+		if current == dir {
+			start = py
+		}
+
+		envs, err := environment.Find(fs)
+		if err != nil {
+			return "", nil, fmt.Errorf("%w", err)
+		}
+		if len(envs) != 0 {
+			return current, py, nil
+		}
+
+		match, err := registry.DetectWith(fs, py)
+		if err != nil {
+			return "", nil, fmt.Errorf("%w", err)
+		}
+		if match.Found() {
+			return current, py, nil
+		}
+
+		isRepoRoot, err := fs.DirExists(gitDir)
+		if err != nil {
+			return "", nil, fmt.Errorf("could not check for %s in %s: %w", gitDir, current, err)
+		}
+
+		parent := filepath.Dir(current)
+		if isRepoRoot || current == home || parent == current {
+			break
+		}
+		current = parent
+	}
+
+	a.logger.WithField("directory", dir).Debugln("No project root found, using cwd")
+	return dir, start, nil
+}
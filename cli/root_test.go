@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestApp_findRoot(t *testing.T) {
+	home := filepath.FromSlash("/home/user")
+	userHomeDir = func() (string, error) { return home, nil }
+	defer func() { userHomeDir = os.UserHomeDir }()
+
+	tests := []struct {
+		name  string
+		files []string
+		dirs  []string
+		start string
+		want  string
+	}{
+		{
+			name:  "project in cwd",
+			files: []string{"/home/user/project/pyproject.toml", "/home/user/project/setup.py"},
+			start: "/home/user/project",
+			want:  "/home/user/project",
+		},
+		{
+			name:  "project above cwd",
+			files: []string{"/home/user/project/requirements.txt"},
+			dirs:  []string{"/home/user/project/src/mypkg"},
+			start: "/home/user/project/src/mypkg",
+			want:  "/home/user/project",
+		},
+		{
+			name:  "environment above cwd",
+			files: []string{"/home/user/project/env/pyvenv.cfg"},
+			dirs:  []string{"/home/user/project/tests"},
+			start: "/home/user/project/tests",
+			want:  "/home/user/project",
+		},
+		{
+			name:  "empty venv directory is not an environment",
+			dirs:  []string{"/tmp/somewhere/venv", "/tmp/somewhere/sub"},
+			start: "/tmp/somewhere/sub",
+			want:  "/tmp/somewhere/sub",
+		},
+		{
+			name:  "stops at git root",
+			files: []string{"/home/user/requirements.txt"},
+			dirs:  []string{"/home/user/repo/.git", "/home/user/repo/src"},
+			start: "/home/user/repo/src",
+			want:  "/home/user/repo/src",
+		},
+		{
+			name:  "stops at home",
+			files: []string{"/home/requirements.txt"},
+			dirs:  []string{"/home/user/scratch"},
+			start: "/home/user/scratch",
+			want:  "/home/user/scratch",
+		},
+		{
+			name:  "nothing anywhere",
+			dirs:  []string{"/tmp/somewhere"},
+			start: "/tmp/somewhere",
+			want:  "/tmp/somewhere",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+			app := New(stdout, stderr, afero.NewMemMapFs(), newTestPrinter(stdout))
+
+			for _, dir := range tt.dirs {
+				if err := app.fs.MkdirAll(filepath.FromSlash(dir), 0o755); err != nil {
+					t.Fatalf("could not create test dir: %v", err)
+				}
+			}
+			for _, file := range tt.files {
+				if err := app.fs.WriteFile(filepath.FromSlash(file), []byte(""), 0o755); err != nil {
+					t.Fatalf("could not create test file: %v", err)
+				}
+			}
+
+			got, _, err := app.findRoot(filepath.FromSlash(tt.start), newRegistry(Options{}))
+			if err != nil {
+				t.Fatalf("findRoot returned an error: %v", err)
+			}
+
+			if want := filepath.FromSlash(tt.want); got != want {
+				t.Errorf("got %q, wanted %q", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/FollowTheProcess/venv/pkg/environment"
+	"github.com/FollowTheProcess/venv/pkg/stamp"
+	"github.com/spf13/afero"
+)
+
+var (
+	errStale     = errors.New("environment is out of date")
+	errUnstamped = errors.New("environment was not built by venv so cannot be checked")
+	errNoEnv     = errors.New("no environment found")
+	errBroken    = errors.New("environment is broken")
+)
+
+// writeStamp fingerprints the project in fs and records it in the environment venv
+// built, so later runs can tell if it's up to date
+//
+// Failing to write the stamp doesn't fail the install, the worst that can happen
+// is an unnecessary sync next time
+func (a *App) writeStamp(fs afero.Afero, patterns []string) {
+	env, ok, err := environment.Read(fs, managedEnv)
+	if err != nil || !ok {
+		a.logger.WithField("error", err).Debugln("no environment to stamp")
+		return
+	}
+
+	fingerprint, err := stamp.Compute(fs, env.Version, patterns)
+	if err == nil {
+		err = stamp.Write(fs, managedEnv, fingerprint)
+	}
+	if err != nil {
+		a.printer.Warnf("Could not record the environment's dependencies: %v", err)
+		return
+	}
+	a.logger.WithField("files", len(fingerprint.Files)).Debugln("environment stamped")
+}
+
+// changes compares the stamp in the environment venv built with the project as it
+// is now and returns what has changed, 'stamped' is false if there is no stamp
+// to compare against
+func (a *App) changes(fs afero.Afero, envs []environment.Environment, patterns []string) (changes []string, stamped bool, err error) {
+	var env environment.Environment
+	for _, e := range envs {
+		if e.Path == managedEnv {
+			env = e
+		}
+	}
+	if env.Path == "" {
+		return nil, false, nil
+	}
+
+	before, ok, err := stamp.Read(fs, env.Path)
+	if err != nil {
+		return nil, false, fmt.Errorf("%w", err)
+	}
+	if !ok {
+		return nil, false, nil
+	}
+
+	now, err := stamp.Compute(fs, env.Version, patterns)
+	if err != nil {
+		return nil, false, fmt.Errorf("%w", err)
+	}
+
+	return before.Changes(now), true, nil
+}
+
+// check is --check mode, it reports whether the project's environment is up to date
+// without changing anything and returns an error if it isn't
+func (a *App) check(fs afero.Afero, envs, unhealthy []environment.Environment, patterns []string) error {
+	if len(envs) == 0 {
+		return errNoEnv
+	}
+	// Broken environments venv doesn't manage aren't it's to check
+	if hasManaged(unhealthy) {
+		return errBroken
+	}
+
+	changes, stamped, err := a.changes(fs, envs, patterns)
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+	if !stamped {
+		// Only the environment venv manages can be synced to fix this
+		if !hasManaged(envs) {
+			return fmt.Errorf("%w, venv only checks the environment in %q", errUnstamped, managedEnv)
+		}
+		return fmt.Errorf("%w, run 'venv --sync' to fix", errUnstamped)
+	}
+
+	if len(changes) != 0 {
+		a.printer.Warn("Dependencies have changed since the environment was built:")
+		for _, change := range changes {
+			a.printer.Textf("  - %s", change)
+		}
+		return errStale
+	}
+
+	a.printer.Good("Environment up to date")
+	return nil
+}
@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/FollowTheProcess/venv/pkg/environment"
+	"github.com/spf13/afero"
+)
+
+func TestApp_check(t *testing.T) {
+	managed := []environment.Environment{{Path: managedEnv, Kind: environment.Venv, Version: "3.10.4"}}
+
+	tests := []struct {
+		wantErr   error
+		name      string
+		envs      []environment.Environment
+		unhealthy []environment.Environment
+		stamp     bool // Whether to stamp the environment before changing anything
+		change    bool // Whether to change requirements.txt after stamping
+	}{
+		{
+			name:    "no environment",
+			envs:    nil,
+			wantErr: errNoEnv,
+		},
+		{
+			name:      "broken",
+			envs:      managed,
+			unhealthy: managed,
+			wantErr:   errBroken,
+		},
+		{
+			name:      "unmanaged environment broken",
+			envs:      append([]environment.Environment{{Path: "env", Kind: environment.Venv}}, managed...),
+			unhealthy: []environment.Environment{{Path: "env", Kind: environment.Venv}},
+			stamp:     true,
+			wantErr:   nil,
+		},
+		{
+			name:    "not stamped",
+			envs:    managed,
+			wantErr: errUnstamped,
+		},
+		{
+			name:    "unmanaged",
+			envs:    []environment.Environment{{Path: "venv", Kind: environment.Venv}},
+			wantErr: errUnstamped,
+		},
+		{
+			name:    "up to date",
+			envs:    managed,
+			stamp:   true,
+			wantErr: nil,
+		},
+		{
+			name:    "stale",
+			envs:    managed,
+			stamp:   true,
+			change:  true,
+			wantErr: errStale,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+			app := New(stdout, stderr, afero.NewMemMapFs(), newTestPrinter(stdout))
+
+			files := map[string]string{
+				".venv/pyvenv.cfg": "version = 3.10.4\n",
+				"requirements.txt": "requests\n",
+			}
+			for file, content := range files {
+				if err := app.fs.WriteFile(file, []byte(content), 0o755); err != nil {
+					t.Fatalf("could not create file: %v", err)
+				}
+			}
+
+			if tt.stamp {
+				app.writeStamp(app.fs, nil)
+			}
+			if tt.change {
+				if err := app.fs.WriteFile("requirements.txt", []byte("requests\nrich\n"), 0o755); err != nil {
+					t.Fatalf("could not change requirements: %v", err)
+				}
+			}
+
+			err := app.check(app.fs, tt.envs, tt.unhealthy, nil)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("got error %v, wanted %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/FollowTheProcess/venv/pkg/tools"
+)
+
+// errMissingTool is returned when a project needs a tool that isn't installed
+// and the user chose not to let venv install it
+var errMissingTool = errors.New("required tool is not installed")
+
+// missingTools and installTool are internal reassignments of their tools
+// equivalents used for mocking during tests
+var (
+	missingTools = tools.Missing
+	installTool  = tools.Install
+)
+
+// ensureTools checks the tools 'names' a plan needs are on $PATH, offering to
+// install any that aren't into venv's own tools directory, --install-tools
+// installs them without asking while --abort, or not running in a terminal, declines
+func (a *App) ensureTools(names []string, options Options) error {
+	missing := missingTools(names)
+	if len(missing) == 0 {
+		return nil
+	}
+
+	dir, err := tools.Dir()
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	a.printer.Warnf("This project needs %s but it is not installed", strings.Join(missing, ", "))
+
+	install := options.InstallTools
+	if !install && !options.Abort && interactive() {
+		prompt := &survey.Confirm{
+			Message: fmt.Sprintf("Install %s into %q for venv to use?", strings.Join(missing, ", "), dir),
+			Default: true,
+		}
+		if err := survey.AskOne(prompt, &install); err != nil {
+			return fmt.Errorf("could not generate prompt: %w", err)
+		}
+	}
+
+	if !install {
+		return fmt.Errorf("%w: %s, install it or run 'venv --install-tools'", errMissingTool, strings.Join(missing, ", "))
+	}
+
+	for _, name := range missing {
+		a.printer.Infof("Installing %s into %q", name, dir)
+		if err := installTool(name, a.stdout, a.stderr); err != nil {
+			return fmt.Errorf("%w", err)
+		}
+	}
+
+	return nil
+}
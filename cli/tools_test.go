@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/FollowTheProcess/venv/pkg/tools"
+	"github.com/spf13/afero"
+)
+
+func TestApp_ensureTools(t *testing.T) {
+	tests := []struct {
+		wantErr   error
+		name      string
+		missing   []string
+		installed []string
+		options   Options
+	}{
+		{
+			name:    "nothing missing",
+			missing: nil,
+		},
+		{
+			name:      "install without asking",
+			missing:   []string{"poetry"},
+			options:   Options{InstallTools: true},
+			installed: []string{"poetry"},
+		},
+		{
+			name:    "abort",
+			missing: []string{"poetry"},
+			options: Options{Abort: true},
+			wantErr: errMissingTool,
+		},
+		{
+			name:    "not a terminal",
+			missing: []string{"poetry"},
+			options: Options{},
+			wantErr: errMissingTool,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+			var installed []string
+			missingTools = func(names []string) []string { return tt.missing }
+			installTool = func(name string, stdout, stderr io.Writer) error {
+				installed = append(installed, name)
+				return nil
+			}
+			interactive = func() bool { return false }
+			defer func() {
+				missingTools = tools.Missing
+				installTool = tools.Install
+				interactive = isTerminal
+			}()
+
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+			app := New(stdout, stderr, afero.NewMemMapFs(), newTestPrinter(stdout))
+
+			err := app.ensureTools([]string{"poetry"}, tt.options)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, wanted %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(installed, tt.installed) {
+				t.Errorf("installed %v, wanted %v", installed, tt.installed)
+			}
+		})
+	}
+}
@@ -0,0 +1,270 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/FollowTheProcess/venv/pkg/environment"
+	"github.com/FollowTheProcess/venv/pkg/project"
+	"github.com/spf13/afero"
+)
+
+// skipDirs are directories never searched for projects in workspace mode, on top
+// of hidden directories which are always skipped
+var skipDirs = map[string]bool{
+	venvDir:         true,
+	"node_modules":  true,
+	"__pycache__":   true,
+	"site-packages": true,
+	"build":         true,
+	"dist":          true,
+}
+
+// Possible outcomes for a project in workspace mode
+const (
+	resultCreated  = "created"
+	resultExisting = "environment already exists"
+	resultSynced   = "synced"
+	resultFailed   = "failed"
+)
+
+// workspaceProject is a single project found in workspace mode
+type workspaceProject struct {
+	err      error                     // The error building the environment, if any
+	dir      string                    // Absolute path to the project
+	existing []environment.Environment // The project's existing environments, if any
+	match    project.Match             // What the detectors made of the project
+	sync     bool                      // Whether the existing environment is to be synced
+}
+
+// result returns the outcome of building the project for the summary
+func (w workspaceProject) result() string {
+	switch {
+	case w.err != nil:
+		return fmt.Sprintf("%s: %v", resultFailed, w.err)
+	case w.sync:
+		return fmt.Sprintf("%s (%s)", resultSynced, managedEnv)
+	case len(w.existing) != 0:
+		return fmt.Sprintf("%s (%s)", resultExisting, w.existing[0].Path)
+	default:
+		return resultCreated
+	}
+}
+
+// dirFs returns a filesystem rooted at 'dir'
+func (a *App) dirFs(dir string) afero.Afero {
+	return afero.Afero{Fs: afero.NewBasePathFs(a.fs.Fs, dir)}
+}
+
+// runAll is workspace mode, it finds every project under 'root', shows the plan
+// for each of them, builds every environment and summarises the results
+//
+// If options.Sync is set, existing environments are synced with their project's dependencies
+// rather than left alone
+func (a *App) runAll(root string, registry *project.Registry, options Options) error {
+	projects, err := a.discoverProjects(root, registry, options.Sync)
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	if len(projects) == 0 {
+		a.printer.Warnf("No projects found under %q", root)
+		return nil
+	}
+
+	a.printer.Infof("Found %d projects under %q", len(projects), root)
+	for _, p := range projects {
+		a.printer.Textf("\n%s", relative(root, p.dir))
+		switch {
+		case p.err != nil, len(p.existing) != 0 && !p.sync:
+			a.printer.Textf("  %s", p.result())
+			continue
+		case p.sync:
+			a.printer.Textf("  Syncing %q with the project's dependencies (%s)", managedEnv, p.match.Detector)
+		default:
+			a.printer.Textf("  %s (%s)", p.match.Plan.Summary, p.match.Detector)
+		}
+		for _, warning := range p.match.Warnings {
+			a.printer.Textf("  Warning: %s", warning)
+		}
+		for _, step := range p.match.Plan.Steps {
+			if p.sync && step.Phase == project.Build || !p.sync && step.Phase == project.Sync {
+				continue
+			}
+			a.printer.Textf("    - %s", step.Description)
+		}
+	}
+	fmt.Fprintln(a.stdout)
+
+	// Sort out any missing tools once up front rather than asking project by project,
+	// any project whose tools are still missing fails on it's own
+	if err := a.ensureTools(workspaceTools(projects), options); err != nil {
+		a.printer.Warnf("%v", err)
+	}
+
+	failed := 0
+	for i, p := range projects {
+		if p.err != nil {
+			// Couldn't even work out what the project is
+			failed++
+			continue
+		}
+		if !p.sync && len(p.existing) != 0 {
+			// Existing environment left alone
+			continue
+		}
+
+		var err error
+		switch missing := missingTools(p.match.Plan.Tools); {
+		case len(missing) != 0:
+			err = fmt.Errorf("%w: %s", errMissingTool, strings.Join(missing, ", "))
+		case p.sync:
+			a.printer.Infof("Syncing %q", relative(root, p.dir))
+			err = p.match.Plan.Sync(p.dir, a.stdout, a.stderr)
+		default:
+			a.printer.Infof("Building %q", relative(root, p.dir))
+			err = p.match.Plan.Execute(p.dir, a.stdout, a.stderr)
+		}
+		if err != nil {
+			a.logger.WithField("project", p.dir).Debugln(err)
+			projects[i].err = err
+			failed++
+			continue
+		}
+		a.writeStamp(a.dirFs(p.dir), options.Requirements)
+	}
+
+	a.summarise(root, projects)
+
+	if failed != 0 {
+		return fmt.Errorf("%d of %d projects failed", failed, len(projects))
+	}
+
+	a.printer.Good("Done")
+	return nil
+}
+
+// workspaceTools returns every tool needed by the projects that will be
+// built or synced, each only once
+func workspaceTools(projects []workspaceProject) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, p := range projects {
+		if !p.sync && len(p.existing) != 0 {
+			continue
+		}
+		for _, name := range p.match.Plan.Tools {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+
+	return names
+}
+
+// discoverProjects walks the tree under 'root' and returns every project in it
+//
+// Once a project is found we don't look any further down that part of the tree,
+// except at 'root' itself as monorepos often have tooling config at the top level
+func (a *App) discoverProjects(root string, registry *project.Registry, sync bool) ([]workspaceProject, error) {
+	var projects []workspaceProject
+
+	err := a.fs.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+
+		name := info.Name()
+		if path != root && (strings.HasPrefix(name, ".") || skipDirs[name]) {
+			return filepath.SkipDir
+		}
+
+		// Environments can be called anything, so recognise them by what's in them
+		// rather than searching through their site-packages for projects
+		if path != root {
+			if _, isEnv, err := environment.Read(a.fs, path); err == nil && isEnv {
+				return filepath.SkipDir
+			}
+		}
+
+		a.logger.WithField("directory", path).Debugln("Looking for project")
+		fs := a.dirFs(path)
+
+		// A project that can't be made sense of fails on it's own, the rest of
+		// the workspace carries on and it shows up in the summary
+		envs, err := environment.Find(fs)
+		if err != nil {
+			a.logger.WithField("project", path).Debugln(err)
+			projects = append(projects, workspaceProject{dir: path, err: err})
+			return skipProject(root, path)
+		}
+
+		found := workspaceProject{dir: path, existing: envs}
+		if len(envs) == 0 || sync && hasManaged(envs) {
+			match, err := registry.Detect(fs)
+			if err != nil {
+				a.logger.WithField("project", path).Debugln(err)
+				found.err = err
+			}
+			switch {
+			case err != nil:
+			case match.Found():
+				found.match = match
+				found.sync = len(envs) != 0
+			case len(envs) == 0:
+				return nil
+			}
+		}
+
+		projects = append(projects, found)
+		return skipProject(root, path)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not search %s for projects: %w", root, err)
+	}
+
+	return projects, nil
+}
+
+// skipProject tells the walk not to look inside a project for more projects,
+// unless it's the root of the workspace
+func skipProject(root, path string) error {
+	if path != root {
+		return filepath.SkipDir
+	}
+	return nil
+}
+
+// summarise prints a table of every project and how building it went
+func (a *App) summarise(root string, projects []workspaceProject) {
+	fmt.Fprintln(a.stdout)
+	w := tabwriter.NewWriter(a.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PROJECT\tTYPE\tRESULT")
+	for _, p := range projects {
+		kind := p.match.Detector
+		if kind == "" {
+			kind = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", relative(root, p.dir), kind, p.result())
+	}
+	w.Flush()
+	fmt.Fprintln(a.stdout)
+}
+
+// relative returns 'path' relative to 'root' for display, falling back to
+// 'path' itself if that's not possible
+func relative(root, path string) string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return path
+	}
+	return rel
+}
@@ -0,0 +1,202 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/FollowTheProcess/venv/pkg/project"
+	"github.com/FollowTheProcess/venv/pkg/pyproject"
+	"github.com/spf13/afero"
+)
+
+// markerDetector recognises any directory containing a file called "marker", if
+// the marker contains "fail" the plan fails and if it contains "broken" detection fails
+type markerDetector struct{}
+
+func (markerDetector) Name() string {
+	return "marker"
+}
+
+func (markerDetector) Detect(fs afero.Afero, py *pyproject.PyProject) (project.Match, error) {
+	exists, err := fs.Exists("marker")
+	if err != nil || !exists {
+		return project.Match{}, err
+	}
+
+	content, err := fs.ReadFile("marker")
+	if err != nil {
+		return project.Match{}, err
+	}
+
+	if strings.Contains(string(content), "broken") {
+		return project.Match{}, errors.New("broken project")
+	}
+
+	run := func(cwd string, stdout, stderr io.Writer) error { return nil }
+	if strings.Contains(string(content), "fail") {
+		run = func(cwd string, stdout, stderr io.Writer) error { return errors.New("bang") }
+	}
+
+	return project.Match{
+		Confidence: project.High,
+		Plan:       project.Plan{Summary: "marker project", Steps: []project.Step{{Description: "build", Run: run}}},
+	}, nil
+}
+
+// newWorkspace creates a test App with 'files' (path to content) and 'dirs' in it's filesystem
+func newWorkspace(t *testing.T, files map[string]string, dirs []string) (*App, *bytes.Buffer) {
+	t.Helper()
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	app := New(stdout, stderr, afero.NewMemMapFs(), newTestPrinter(stdout))
+
+	for _, dir := range dirs {
+		if err := app.fs.MkdirAll(filepath.FromSlash(dir), 0o755); err != nil {
+			t.Fatalf("could not create test dir: %v", err)
+		}
+	}
+	for file, content := range files {
+		if err := app.fs.WriteFile(filepath.FromSlash(file), []byte(content), 0o755); err != nil {
+			t.Fatalf("could not create test file: %v", err)
+		}
+	}
+
+	return app, stdout
+}
+
+func TestApp_discoverProjects(t *testing.T) {
+	registry := project.NewRegistry()
+	registry.Register(1, markerDetector{})
+
+	app, _ := newWorkspace(t,
+		map[string]string{
+			"/repo/marker":                           "",
+			"/repo/services/api/marker":              "",
+			"/repo/services/api/docs/marker":         "", // Inside a project, not searched
+			"/repo/services/worker/marker":           "",
+			"/repo/services/worker/.venv/pyvenv.cfg": "",
+			"/repo/node_modules/thing/marker":        "",
+			"/repo/.hidden/marker":                   "",
+			"/repo/libs/shared/marker":               "",
+			"/repo/env/pyvenv.cfg":                   "", // Environments are never searched, whatever they're called
+			"/repo/env/lib/python3.10/thing/marker":  "",
+			"/repo/conda/conda-meta/history":         "",
+			"/repo/conda/lib/thing/marker":           "",
+		},
+		[]string{"/repo/services/empty"},
+	)
+
+	projects, err := app.discoverProjects(filepath.FromSlash("/repo"), registry, false)
+	if err != nil {
+		t.Fatalf("discoverProjects returned an error: %v", err)
+	}
+
+	var got []string
+	for _, p := range projects {
+		got = append(got, filepath.ToSlash(p.dir))
+	}
+
+	want := []string{"/repo", "/repo/libs/shared", "/repo/services/api", "/repo/services/worker"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, wanted %v", got, want)
+	}
+
+	if len(projects[3].existing) == 0 {
+		t.Errorf("project %s should have been marked as having an environment", projects[3].dir)
+	}
+}
+
+func TestApp_runAll(t *testing.T) {
+	registry := project.NewRegistry()
+	registry.Register(1, markerDetector{})
+
+	t.Run("all succeed", func(t *testing.T) {
+		app, stdout := newWorkspace(t, map[string]string{
+			"/repo/one/marker": "",
+			"/repo/two/marker": "",
+		}, nil)
+
+		if err := app.runAll(filepath.FromSlash("/repo"), registry, Options{}); err != nil {
+			t.Fatalf("runAll returned an error: %v", err)
+		}
+
+		if !strings.Contains(stdout.String(), "PROJECT") {
+			t.Errorf("summary table missing from output: %s", stdout.String())
+		}
+
+		if got := strings.Count(stdout.String(), resultCreated); got != 2 {
+			t.Errorf("expected 2 created projects, got %d: %s", got, stdout.String())
+		}
+	})
+
+	t.Run("failures are reported", func(t *testing.T) {
+		app, stdout := newWorkspace(t, map[string]string{
+			"/repo/one/marker": "",
+			"/repo/two/marker": "fail",
+		}, nil)
+
+		err := app.runAll(filepath.FromSlash("/repo"), registry, Options{})
+		if err == nil {
+			t.Fatal("runAll did not return an error")
+		}
+
+		if !strings.Contains(err.Error(), "1 of 2") {
+			t.Errorf("wrong error message: %v", err)
+		}
+
+		if !strings.Contains(stdout.String(), "failed: bang") {
+			t.Errorf("failure missing from summary: %s", stdout.String())
+		}
+	})
+
+	t.Run("projects that can't be detected are reported", func(t *testing.T) {
+		app, stdout := newWorkspace(t, map[string]string{
+			"/repo/one/marker":   "",
+			"/repo/three/marker": "",
+			"/repo/two/marker":   "broken",
+		}, nil)
+
+		err := app.runAll(filepath.FromSlash("/repo"), registry, Options{})
+		if err == nil {
+			t.Fatal("runAll did not return an error")
+		}
+
+		if !strings.Contains(err.Error(), "1 of 3") {
+			t.Errorf("wrong error message: %v", err)
+		}
+
+		if got := strings.Count(stdout.String(), resultCreated); got != 2 {
+			t.Errorf("expected the other 2 projects to be created, got %d: %s", got, stdout.String())
+		}
+
+		if !strings.Contains(stdout.String(), "broken project") {
+			t.Errorf("failure missing from summary: %s", stdout.String())
+		}
+	})
+
+	t.Run("sync existing environments", func(t *testing.T) {
+		app, stdout := newWorkspace(t, map[string]string{
+			"/repo/one/marker":           "",
+			"/repo/one/.venv/pyvenv.cfg": "",
+			"/repo/two/marker":           "",
+			"/repo/two/env/pyvenv.cfg":   "", // Not managed by venv, left alone
+		}, nil)
+
+		if err := app.runAll(filepath.FromSlash("/repo"), registry, Options{Sync: true}); err != nil {
+			t.Fatalf("runAll returned an error: %v", err)
+		}
+
+		if !strings.Contains(stdout.String(), resultSynced) {
+			t.Errorf("synced project missing from summary: %s", stdout.String())
+		}
+
+		if !strings.Contains(stdout.String(), resultExisting) {
+			t.Errorf("unmanaged environment should have been left alone: %s", stdout.String())
+		}
+	})
+}
@@ -4,17 +4,47 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/FollowTheProcess/msg"
 	"github.com/FollowTheProcess/venv/cli"
+	"github.com/FollowTheProcess/venv/pkg/poetry"
 	"github.com/spf13/afero"
 )
 
 var (
-	help    bool // The --help flag
-	version bool // The --version flag
-	create  bool // The --create flag to bypass the interactive prompt
-	abort   bool // The --abort flag to bypass the interactive prompt
+	help     bool   // The --help flag
+	version  bool   // The --version flag
+	create   bool   // The --create flag to bypass the interactive prompt
+	abort    bool   // The --abort flag to bypass the interactive prompt
+	all      bool   // The --all flag to run in workspace mode
+	recreate bool   // The --recreate flag to recreate broken environments
+	sync     bool   // The --sync flag to sync an existing environment
+	check    bool   // The --check flag to check the environment is up to date
+	install  bool   // The --install-tools flag to install missing tools without asking
+	with     string // The --with flag to choose optional poetry groups
+	without  string // The --without flag to leave out poetry groups
+	allExtra bool   // The --all-extras flag to install every poetry extra
+	pSync    bool   // The --poetry-sync flag to pass --sync to poetry install
+	noRoot   bool   // The --no-root flag to pass --no-root to poetry install
+	pLock    bool   // The --poetry-lock flag to update a stale poetry.lock without asking
+	extras   string // The --extras flag to choose which extras to install
+	backend  string // The --backend flag to choose the python backend
+	reqs     string // The --requirements flag to add requirements file patterns
+)
+
+// Environment variables used as the defaults for flags
+const (
+	extrasEnv   = "VENV_EXTRAS"
+	backendEnv  = "VENV_BACKEND"
+	reqsEnv     = "VENV_REQUIREMENTS"
+	toolsEnv    = "VENV_INSTALL_TOOLS"
+	withEnv     = "VENV_POETRY_WITH"
+	withoutEnv  = "VENV_POETRY_WITHOUT"
+	allExtraEnv = "VENV_POETRY_ALL_EXTRAS"
+	pSyncEnv    = "VENV_POETRY_SYNC"
+	noRootEnv   = "VENV_POETRY_NO_ROOT"
+	pLockEnv    = "VENV_POETRY_LOCK"
 )
 
 func main() {
@@ -23,6 +53,21 @@ func main() {
 	flag.BoolVar(&version, "version", false, "--version")
 	flag.BoolVar(&create, "create", false, "--create")
 	flag.BoolVar(&abort, "abort", false, "--abort")
+	flag.BoolVar(&all, "all", false, "--all")
+	flag.BoolVar(&recreate, "recreate", false, "--recreate")
+	flag.BoolVar(&sync, "sync", false, "--sync")
+	flag.BoolVar(&sync, "s", false, "--sync")
+	flag.BoolVar(&check, "check", false, "--check")
+	flag.BoolVar(&install, "install-tools", os.Getenv(toolsEnv) != "", "--install-tools")
+	flag.StringVar(&extras, "extras", os.Getenv(extrasEnv), "--extras")
+	flag.StringVar(&backend, "backend", os.Getenv(backendEnv), "--backend")
+	flag.StringVar(&reqs, "requirements", os.Getenv(reqsEnv), "--requirements")
+	flag.StringVar(&with, "with", os.Getenv(withEnv), "--with")
+	flag.StringVar(&without, "without", os.Getenv(withoutEnv), "--without")
+	flag.BoolVar(&allExtra, "all-extras", os.Getenv(allExtraEnv) != "", "--all-extras")
+	flag.BoolVar(&pSync, "poetry-sync", os.Getenv(pSyncEnv) != "", "--poetry-sync")
+	flag.BoolVar(&noRoot, "no-root", os.Getenv(noRootEnv) != "", "--no-root")
+	flag.BoolVar(&pLock, "poetry-lock", os.Getenv(pLockEnv) != "", "--poetry-lock")
 
 	app := cli.New(os.Stdout, os.Stderr, afero.NewOsFs(), msg.Default())
 
@@ -44,9 +89,41 @@ func main() {
 		app.Version()
 	default:
 		// Run the actual program
-		if err := app.Run(create, abort); err != nil {
+		options := cli.Options{
+			Create:       create,
+			Abort:        abort,
+			Recreate:     recreate,
+			Sync:         sync,
+			Check:        check,
+			All:          all,
+			InstallTools: install,
+			Extras:       splitList(extras),
+			Backend:      backend,
+			Requirements: splitList(reqs),
+			Poetry: poetry.Options{
+				With:      splitList(with),
+				Without:   splitList(without),
+				AllExtras: allExtra,
+				Sync:      pSync,
+				NoRoot:    noRoot,
+			},
+			PoetryLock: pLock,
+		}
+		if err := app.Run(options); err != nil {
 			msg.Failf("%s", err)
 			os.Exit(1)
 		}
 	}
 }
+
+// splitList splits a comma separated list from the command line, dropping empty items
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
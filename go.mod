@@ -9,6 +9,7 @@ require (
 	github.com/pelletier/go-toml/v2 v2.0.2
 	github.com/sirupsen/logrus v1.9.0
 	github.com/spf13/afero v1.9.2
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -0,0 +1,224 @@
+// Package conda implements support for projects declaring a conda environment
+// in an environment.yml, creating it with whichever of conda, mamba or
+// micromamba is installed
+package conda
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+
+	"github.com/FollowTheProcess/venv/pkg/project"
+	"github.com/FollowTheProcess/venv/pkg/pyproject"
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	condaCommand = exec.Command
+	lookPath     = exec.LookPath
+)
+
+// prefix is where the environment is created, relative to the project
+const prefix = "./.venv"
+
+// envFiles are the names conda accepts for an environment file, in order of preference
+var envFiles = []string{"environment.yml", "environment.yaml"}
+
+// tools are the executables able to create an environment from an environment file,
+// in order of preference
+var tools = []string{"conda", "mamba", "micromamba"}
+
+// errNoTool is returned when none of the conda tools are installed
+var errNoTool = errors.New("none of conda, mamba or micromamba found on $PATH")
+
+// Environment is the parsed contents of a conda environment file
+type Environment struct {
+	Name         string   // The environment name, unused as the environment is created by prefix
+	Channels     []string // Channels to install conda packages from
+	Dependencies []string // Conda package specs
+	Pip          []string // Requirements from the nested pip: section
+}
+
+// environmentYAML is the raw shape of an environment file, where a dependency may
+// either be a conda package spec or a mapping holding the pip requirements
+type environmentYAML struct {
+	Name         string      `yaml:"name"`
+	Channels     []string    `yaml:"channels"`
+	Dependencies []yaml.Node `yaml:"dependencies"`
+}
+
+// ReadEnvironment reads and parses the conda environment file given by 'path'
+func ReadEnvironment(af afero.Afero, path string) (Environment, error) {
+	data, err := af.ReadFile(path)
+	if err != nil {
+		return Environment{}, fmt.Errorf("could not read %s: %w", path, err)
+	}
+
+	var raw environmentYAML
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return Environment{}, fmt.Errorf("could not unmarshall yaml data: %w", err)
+	}
+
+	env := Environment{Name: raw.Name, Channels: raw.Channels}
+	for _, node := range raw.Dependencies {
+		node := node
+		switch node.Kind {
+		case yaml.ScalarNode:
+			env.Dependencies = append(env.Dependencies, node.Value)
+		case yaml.MappingNode:
+			var nested map[string][]string
+			if err := node.Decode(&nested); err != nil {
+				return Environment{}, fmt.Errorf("%s line %d: could not decode dependency: %w", path, node.Line, err)
+			}
+			env.Pip = append(env.Pip, nested["pip"]...)
+		default:
+			return Environment{}, fmt.Errorf("%s line %d: unexpected dependency %q", path, node.Line, node.Value)
+		}
+	}
+
+	return env, nil
+}
+
+// newCondaCommand returns an exec.Cmd for 'tool' configured with the parameters passed in
+func newCondaCommand(tool, cwd string, stdout, stderr io.Writer, args []string) *exec.Cmd {
+	cmd := condaCommand(tool, args...)
+	cmd.Dir = cwd
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	return cmd
+}
+
+// createArgs returns the arguments to 'tool' to create a prefix environment from 'file'
+func createArgs(tool, file string) []string {
+	if tool == "micromamba" {
+		return []string{"create", "--yes", "--prefix", prefix, "--file", file}
+	}
+	return []string{"env", "create", "--prefix", prefix, "--file", file}
+}
+
+// Create uses 'tool' (conda, mamba or micromamba) to create a prefix environment
+// in cwd from the environment file 'file', including any pip requirements
+func Create(tool, cwd string, stdout, stderr io.Writer, file string) error {
+	cmd := newCondaCommand(tool, cwd, stdout, stderr, createArgs(tool, file))
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("could not create conda environment: %w", err)
+	}
+
+	return nil
+}
+
+// updateArgs returns the arguments to 'tool' to update an existing prefix environment
+// so it matches 'file'
+func updateArgs(tool, file string) []string {
+	if tool == "micromamba" {
+		return []string{"install", "--yes", "--prefix", prefix, "--file", file}
+	}
+	return []string{"env", "update", "--prune", "--prefix", prefix, "--file", file}
+}
+
+// Update uses 'tool' (conda, mamba or micromamba) to bring the existing prefix
+// environment in cwd in line with the environment file 'file'
+func Update(tool, cwd string, stdout, stderr io.Writer, file string) error {
+	cmd := newCondaCommand(tool, cwd, stdout, stderr, updateArgs(tool, file))
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("could not update conda environment: %w", err)
+	}
+
+	return nil
+}
+
+// findTool returns the first of the conda tools found on $PATH
+func findTool() (string, error) {
+	for _, tool := range tools {
+		if _, err := lookPath(tool); err == nil {
+			return tool, nil
+		}
+	}
+
+	return "", errNoTool
+}
+
+// Detector recognises projects with a conda environment file
+type Detector struct{}
+
+// Name implements project.Detector
+func (Detector) Name() string {
+	return "conda"
+}
+
+// Detect implements project.Detector
+func (Detector) Detect(fs afero.Afero, _ *pyproject.PyProject) (project.Match, error) {
+	var file string
+	for _, candidate := range envFiles {
+		exists, err := fs.Exists(candidate)
+		if err != nil {
+			return project.Match{}, fmt.Errorf("could not check for %s: %w", candidate, err)
+		}
+		if exists {
+			file = candidate
+			break
+		}
+	}
+	if file == "" {
+		return project.Match{}, nil
+	}
+
+	env, err := ReadEnvironment(fs, file)
+	if err != nil {
+		return project.Match{}, fmt.Errorf("%w", err)
+	}
+
+	reasons := []string{
+		fmt.Sprintf("found %s", file),
+		fmt.Sprintf("%d conda dependencies from channels %v", len(env.Dependencies), env.Channels),
+	}
+	if len(env.Pip) != 0 {
+		reasons = append(reasons, fmt.Sprintf("%d pip dependencies", len(env.Pip)))
+	}
+
+	tool, err := findTool()
+	if err != nil {
+		return project.Match{
+			Confidence: project.High,
+			Reasons:    reasons,
+			Plan: project.Plan{
+				Summary: fmt.Sprintf("Found %q", file),
+				Steps: []project.Step{
+					{
+						Description: "create conda environment",
+						Run: func(cwd string, stdout, stderr io.Writer) error {
+							return fmt.Errorf("cannot create environment from %s: %w", file, errNoTool)
+						},
+					},
+				},
+			},
+		}, nil
+	}
+
+	return project.Match{
+		Confidence: project.High,
+		Reasons:    append(reasons, fmt.Sprintf("using %s", tool)),
+		Plan: project.Plan{
+			Summary: fmt.Sprintf("Found %q. Creating conda environment in %q with %s", file, prefix, tool),
+			Steps: []project.Step{
+				{
+					Description: fmt.Sprintf("%s create environment from %s", tool, file),
+					Run: func(cwd string, stdout, stderr io.Writer) error {
+						return Create(tool, cwd, stdout, stderr, file)
+					},
+					Phase: project.Build,
+				},
+				{
+					Description: fmt.Sprintf("%s update environment from %s", tool, file),
+					Run: func(cwd string, stdout, stderr io.Writer) error {
+						return Update(tool, cwd, stdout, stderr, file)
+					},
+					Phase: project.Sync,
+				},
+			},
+		},
+	}, nil
+}
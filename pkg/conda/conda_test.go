@@ -0,0 +1,310 @@
+package conda
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"reflect"
+	"testing"
+
+	"github.com/FollowTheProcess/venv/pkg/project"
+	"github.com/spf13/afero"
+)
+
+// testCase is used as an env var to pass around so our test helper
+// knows what condition to test for
+var testCase string
+
+const envContent = `name: analysis
+channels:
+  - conda-forge
+  - defaults
+dependencies:
+  - python=3.10
+  - numpy>=1.23
+  - pandas
+  - pip
+  - pip:
+      - requests==2.28.1
+      - -e .
+`
+
+// extractCmdArgs is a helper for TestHelperProcess which teases out the desired
+// external command arguments from the special ones required to make go test use the
+// helper process
+func extractCmdArgs(args []string) []string {
+	for len(args) > 0 {
+		if args[0] == "--" {
+			args = args[1:]
+			break
+		}
+		args = args[1:]
+	}
+	return args
+}
+
+// assertCorrectArgs compares external command arguments to verify correctness
+// designed to be used inside the external command TestHelperProcess
+func assertCorrectArgs(expected, args []string) {
+	if !reflect.DeepEqual(args, expected) {
+		fmt.Fprintf(os.Stderr, "Error: expected cmd %#v, got %#v", expected, args)
+		os.Exit(1)
+	}
+}
+
+// fakeExecCommand is a helper that creates a fake external command
+// It does some clever magic and uses the way go test runs to insert itself
+// during a test in place of an actual command
+// it's used in the std lib to test exec
+// see: https://npf.io/2015/06/testing-exec-command/
+func fakeExecCommand(command string, args ...string) *exec.Cmd {
+	cs := []string{"-test.run=TestCondaHelperProcess", "--", command}
+	cs = append(cs, args...)
+
+	cmd := exec.Command(os.Args[0], cs...)
+	// By passing env variables like this, we can control the behaviour of our
+	// mocked command
+	// For example, have it return a non-zero exit code etc.
+	tc := "CONDA_TEST_CASE=" + testCase
+	cmd.Env = []string{"GO_WANT_CONDA_HELPER_PROCESS=1", tc}
+	return cmd
+}
+
+// fakeLookPath returns a lookPath that only finds the executables in 'found'
+func fakeLookPath(found ...string) func(string) (string, error) {
+	return func(file string) (string, error) {
+		for _, f := range found {
+			if f == file {
+				return "/usr/bin/" + file, nil
+			}
+		}
+		return "", errors.New("not found")
+	}
+}
+
+func setUp(testcase string) {
+	condaCommand = fakeExecCommand
+	testCase = testcase
+}
+
+func tearDown() {
+	condaCommand = exec.Command
+	lookPath = exec.LookPath
+}
+
+// This is the main helper process for external command tests. It first checks whether or not go test wants to use it
+// by looking for the GO_WANT_HELPER_PROCESS env var (which is set by our faked external command)
+// it will then separate out the arguments required to get go test to insert it from our actual
+// external command arguments.
+//
+// It will then switch on the value of the TEST_CASE env var which each test sets individually so that it
+// knows what to do
+// i.e. return a 0 exit code and a success message to verify our happy path, or a non-zero exit code
+// and a message to stderr to test our error handling
+func TestCondaHelperProcess(t *testing.T) {
+	// Tell go test to use this helper if env var is set
+	if os.Getenv("GO_WANT_CONDA_HELPER_PROCESS") != "1" {
+		return
+	}
+
+	// First separate the go test args from what we actually want
+	args := extractCmdArgs(os.Args)
+
+	switch os.Getenv("CONDA_TEST_CASE") {
+	case "conda_create_success":
+		expectedArgs := []string{"conda", "env", "create", "--prefix", "./.venv", "--file", "environment.yml"}
+		assertCorrectArgs(expectedArgs, args)
+
+	case "micromamba_create_success":
+		expectedArgs := []string{"micromamba", "create", "--yes", "--prefix", "./.venv", "--file", "environment.yml"}
+		assertCorrectArgs(expectedArgs, args)
+
+	case "conda_update_success":
+		expectedArgs := []string{"conda", "env", "update", "--prune", "--prefix", "./.venv", "--file", "environment.yml"}
+		assertCorrectArgs(expectedArgs, args)
+
+	case "micromamba_update_success":
+		expectedArgs := []string{"micromamba", "install", "--yes", "--prefix", "./.venv", "--file", "environment.yml"}
+		assertCorrectArgs(expectedArgs, args)
+
+	case "create_error", "update_error":
+		// Simulate failure by printing to stderr and exit 1
+		fmt.Fprintf(os.Stderr, "something wrong")
+		os.Exit(1)
+	}
+}
+
+func TestCreate(t *testing.T) {
+	tests := []struct {
+		testcase string
+		tool     string
+		wantErr  bool
+	}{
+		{
+			testcase: "conda_create_success",
+			tool:     "conda",
+			wantErr:  false,
+		},
+		{
+			testcase: "micromamba_create_success",
+			tool:     "micromamba",
+			wantErr:  false,
+		},
+		{
+			testcase: "create_error",
+			tool:     "mamba",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testcase, func(t *testing.T) {
+			setUp(tt.testcase)
+			defer tearDown()
+
+			if err := Create(tt.tool, ".", os.Stdout, os.Stderr, "environment.yml"); (err != nil) != tt.wantErr {
+				t.Errorf("Create() error = %v, wantErr = %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	tests := []struct {
+		testcase string
+		tool     string
+		wantErr  bool
+	}{
+		{
+			testcase: "conda_update_success",
+			tool:     "conda",
+			wantErr:  false,
+		},
+		{
+			testcase: "micromamba_update_success",
+			tool:     "micromamba",
+			wantErr:  false,
+		},
+		{
+			testcase: "update_error",
+			tool:     "mamba",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testcase, func(t *testing.T) {
+			setUp(tt.testcase)
+			defer tearDown()
+
+			if err := Update(tt.tool, ".", os.Stdout, os.Stderr, "environment.yml"); (err != nil) != tt.wantErr {
+				t.Errorf("Update() error = %v, wantErr = %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReadEnvironment(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		af := afero.Afero{Fs: afero.NewMemMapFs()}
+		if err := af.WriteFile("environment.yml", []byte(envContent), 0o755); err != nil {
+			t.Fatalf("could not create file: %v", err)
+		}
+
+		got, err := ReadEnvironment(af, "environment.yml")
+		if err != nil {
+			t.Fatalf("ReadEnvironment returned an error: %v", err)
+		}
+
+		want := Environment{
+			Name:         "analysis",
+			Channels:     []string{"conda-forge", "defaults"},
+			Dependencies: []string{"python=3.10", "numpy>=1.23", "pandas", "pip"},
+			Pip:          []string{"requests==2.28.1", "-e ."},
+		}
+
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %#v, wanted %#v", got, want)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		af := afero.Afero{Fs: afero.NewMemMapFs()}
+		if err := af.WriteFile("environment.yml", []byte("dependencies: [\n"), 0o755); err != nil {
+			t.Fatalf("could not create file: %v", err)
+		}
+
+		if _, err := ReadEnvironment(af, "environment.yml"); err == nil {
+			t.Error("ReadEnvironment did not return an error")
+		}
+	})
+}
+
+func TestDetector_Detect(t *testing.T) {
+	tests := []struct {
+		name       string
+		file       string
+		step       string
+		found      []string
+		confidence project.Confidence
+	}{
+		{
+			name:       "nothing",
+			file:       "",
+			confidence: project.None,
+		},
+		{
+			name:       "environment.yml with conda",
+			file:       "environment.yml",
+			found:      []string{"conda", "mamba"},
+			step:       "conda create environment from environment.yml",
+			confidence: project.High,
+		},
+		{
+			name:       "environment.yaml with micromamba",
+			file:       "environment.yaml",
+			found:      []string{"micromamba"},
+			step:       "micromamba create environment from environment.yaml",
+			confidence: project.High,
+		},
+		{
+			name:       "no tools",
+			file:       "environment.yml",
+			found:      nil,
+			step:       "create conda environment",
+			confidence: project.High,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lookPath = fakeLookPath(tt.found...)
+			defer tearDown()
+
+			af := afero.Afero{Fs: afero.NewMemMapFs()}
+			if tt.file != "" {
+				if err := af.WriteFile(tt.file, []byte(envContent), 0o755); err != nil {
+					t.Fatalf("could not create file: %v", err)
+				}
+			}
+
+			got, err := Detector{}.Detect(af, nil)
+			if err != nil {
+				t.Fatalf("Detect() returned an error: %v", err)
+			}
+
+			if got.Confidence != tt.confidence {
+				t.Errorf("got confidence %v, wanted %v", got.Confidence, tt.confidence)
+			}
+
+			if tt.step == "" {
+				return
+			}
+
+			if step := got.Plan.Steps[0].Description; step != tt.step {
+				t.Errorf("got step %q, wanted %q", step, tt.step)
+			}
+		})
+	}
+}
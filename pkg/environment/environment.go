@@ -0,0 +1,155 @@
+// Package environment finds existing python environments in a project and reads
+// what can be known about them without running anything
+package environment
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+const (
+	pyvenvCfg = "pyvenv.cfg" // Written into the root of every virtual environment
+	condaMeta = "conda-meta" // Present in the root of every conda prefix
+)
+
+// Kind is the type of an environment
+type Kind string
+
+const (
+	Venv  Kind = "venv"  // A standard virtual environment (venv, virtualenv, uv etc.)
+	Conda Kind = "conda" // A conda prefix environment
+)
+
+// Environment is an existing python environment
+type Environment struct {
+	Config  map[string]string // The raw key value pairs from pyvenv.cfg, nil for conda environments
+	Path    string            // The environment's directory
+	Kind    Kind              // What type of environment it is
+	Version string            // The python version, empty if it could not be determined
+}
+
+// Find looks in the immediate subdirectories of the root of 'fs' and returns
+// every environment found, sorted by path
+//
+// Environments are recognised by their contents (pyvenv.cfg or conda-meta)
+// rather than their name, so .venv, venv, env or anything else are all found
+func Find(fs afero.Afero) ([]Environment, error) {
+	entries, err := fs.ReadDir(".")
+	if err != nil {
+		return nil, fmt.Errorf("could not read directory: %w", err)
+	}
+
+	var envs []Environment
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		env, ok, err := Read(fs, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("%w", err)
+		}
+		if ok {
+			envs = append(envs, env)
+		}
+	}
+
+	sort.Slice(envs, func(i, j int) bool { return envs[i].Path < envs[j].Path })
+
+	return envs, nil
+}
+
+// Read returns the environment in directory 'dir', ok is false if 'dir'
+// is not an environment
+func Read(fs afero.Afero, dir string) (env Environment, ok bool, err error) {
+	cfg := path.Join(dir, pyvenvCfg)
+	isVenv, err := fs.Exists(cfg)
+	if err != nil {
+		return Environment{}, false, fmt.Errorf("could not check for %s: %w", cfg, err)
+	}
+	if isVenv {
+		data, err := fs.ReadFile(cfg)
+		if err != nil {
+			return Environment{}, false, fmt.Errorf("could not read %s: %w", cfg, err)
+		}
+		config := ParseConfig(data)
+		return Environment{Path: dir, Kind: Venv, Version: configVersion(config), Config: config}, true, nil
+	}
+
+	meta := path.Join(dir, condaMeta)
+	isConda, err := fs.DirExists(meta)
+	if err != nil {
+		return Environment{}, false, fmt.Errorf("could not check for %s: %w", meta, err)
+	}
+	if isConda {
+		version, err := condaVersion(fs, meta)
+		if err != nil {
+			return Environment{}, false, fmt.Errorf("%w", err)
+		}
+		return Environment{Path: dir, Kind: Conda, Version: version}, true, nil
+	}
+
+	return Environment{}, false, nil
+}
+
+// ParseConfig parses the contents of a pyvenv.cfg file into it's key value pairs
+func ParseConfig(data []byte) map[string]string {
+	config := make(map[string]string)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), "=")
+		if !found {
+			continue
+		}
+		config[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+
+	return config
+}
+
+// configVersion returns the python version recorded in a parsed pyvenv.cfg
+//
+// The venv module writes "version", virtualenv writes "version_info" as
+// e.g. "3.10.4.final.0" so only the first three parts are kept
+func configVersion(config map[string]string) string {
+	if version := config["version"]; version != "" {
+		return version
+	}
+
+	parts := strings.Split(config["version_info"], ".")
+	if len(parts) > 3 {
+		parts = parts[:3]
+	}
+
+	return strings.Join(parts, ".")
+}
+
+// condaVersion returns the python version installed in a conda prefix, taken from
+// the python package's metadata file name e.g. conda-meta/python-3.10.4-h12debd9_0.json
+func condaVersion(fs afero.Afero, meta string) (string, error) {
+	entries, err := fs.ReadDir(meta)
+	if err != nil {
+		return "", fmt.Errorf("could not read %s: %w", meta, err)
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, "python-") || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		parts := strings.Split(name, "-")
+		// Guard against e.g. python-dateutil-2.8.2-pyhd8ed1ab_0.json
+		if len(parts) == 3 && len(parts[1]) > 0 && parts[1][0] >= '0' && parts[1][0] <= '9' {
+			return parts[1], nil
+		}
+	}
+
+	return "", nil
+}
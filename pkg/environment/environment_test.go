@@ -0,0 +1,88 @@
+package environment
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+const venvCfg = `home = /usr/local/bin
+include-system-site-packages = false
+version = 3.10.4
+`
+
+const virtualenvCfg = `home = /usr/bin
+implementation = CPython
+version_info = 3.9.13.final.0
+virtualenv = 20.16.3
+`
+
+func TestParseConfig(t *testing.T) {
+	got := ParseConfig([]byte(venvCfg))
+
+	want := map[string]string{
+		"home":                         "/usr/local/bin",
+		"include-system-site-packages": "false",
+		"version":                      "3.10.4",
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, wanted %#v", got, want)
+	}
+}
+
+func TestFind(t *testing.T) {
+	af := afero.Afero{Fs: afero.NewMemMapFs()}
+
+	files := map[string]string{
+		".venv/pyvenv.cfg": venvCfg,
+		"env/pyvenv.cfg":   virtualenvCfg,
+		"prefix/conda-meta/python-dateutil-2.8.2-0.json":  "{}",
+		"prefix/conda-meta/python-3.10.4-h12debd9_0.json": "{}",
+		"venv/README":          "not an environment",
+		"src/demo/__init__.py": "",
+		"pyproject.toml":       "",
+	}
+	for file, content := range files {
+		if err := af.WriteFile(file, []byte(content), 0o755); err != nil {
+			t.Fatalf("could not create file: %v", err)
+		}
+	}
+
+	got, err := Find(af)
+	if err != nil {
+		t.Fatalf("Find returned an error: %v", err)
+	}
+
+	// Config checked separately
+	for i := range got {
+		got[i].Config = nil
+	}
+
+	want := []Environment{
+		{Path: ".venv", Kind: Venv, Version: "3.10.4"},
+		{Path: "env", Kind: Venv, Version: "3.9.13"},
+		{Path: "prefix", Kind: Conda, Version: "3.10.4"},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, wanted %#v", got, want)
+	}
+}
+
+func TestFind_Empty(t *testing.T) {
+	af := afero.Afero{Fs: afero.NewMemMapFs()}
+	if err := af.Mkdir("venv", 0o755); err != nil {
+		t.Fatalf("could not create dir: %v", err)
+	}
+
+	got, err := Find(af)
+	if err != nil {
+		t.Fatalf("Find returned an error: %v", err)
+	}
+
+	if len(got) != 0 {
+		t.Errorf("empty directory was treated as an environment: %#v", got)
+	}
+}
@@ -0,0 +1,124 @@
+package environment
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+
+	"github.com/spf13/afero"
+)
+
+// interpreters are the possible locations of an environment's python, relative to it's root
+var interpreters = []string{"bin/python", "Scripts/python.exe", "python.exe"}
+
+// Health is the result of checking an environment
+type Health struct {
+	Problems []string // Everything found to be wrong with the environment
+}
+
+// OK reports whether the environment is healthy
+func (h Health) OK() bool {
+	return len(h.Problems) == 0
+}
+
+// Check inspects 'env' for the common ways an environment breaks: a missing or
+// dangling interpreter, a base interpreter that has been removed (e.g. by a brew or
+// pyenv upgrade) or a python version no longer allowed by 'requiresPython', if
+// 'requiresPython' isn't a valid version specifier that check is skipped
+//
+// 'project' is the filesystem rooted at the project holding the environment, whereas
+// 'system' is used to look up the absolute paths to the base interpreter
+func Check(project, system afero.Afero, env Environment, requiresPython string) (Health, error) {
+	var health Health
+
+	problem, err := checkInterpreter(project, env)
+	if err != nil {
+		return Health{}, fmt.Errorf("%w", err)
+	}
+	if problem != "" {
+		health.Problems = append(health.Problems, problem)
+	}
+
+	if env.Kind == Venv {
+		problems, err := checkBase(system, env)
+		if err != nil {
+			return Health{}, fmt.Errorf("%w", err)
+		}
+		health.Problems = append(health.Problems, problems...)
+	}
+
+	if requiresPython != "" && env.Version != "" {
+		// A requires-python we can't make sense of (e.g. poetry's "^3.8") is the
+		// project's problem rather than the environment's, so it isn't checked
+		ok, err := Satisfies(env.Version, requiresPython)
+		if err == nil && !ok {
+			health.Problems = append(health.Problems, fmt.Sprintf("python %s does not satisfy requires-python %q", env.Version, requiresPython))
+		}
+	}
+
+	return health, nil
+}
+
+// checkInterpreter makes sure the environment has a working python
+func checkInterpreter(project afero.Afero, env Environment) (string, error) {
+	for _, interpreter := range interpreters {
+		file := path.Join(env.Path, interpreter)
+
+		_, err := project.Stat(file)
+		if err == nil {
+			return "", nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("could not stat %s: %w", file, err)
+		}
+
+		// Stat follows symlinks, so if it's not there but Lstat finds it
+		// the link is dangling
+		lstater, ok := project.Fs.(afero.Lstater)
+		if !ok {
+			continue
+		}
+		if _, _, err := lstater.LstatIfPossible(file); err != nil {
+			continue
+		}
+
+		target := "unknown"
+		if reader, ok := project.Fs.(afero.LinkReader); ok {
+			if link, err := reader.ReadlinkIfPossible(file); err == nil {
+				target = link
+			}
+		}
+		return fmt.Sprintf("%s is a dangling symlink to %s", file, target), nil
+	}
+
+	return fmt.Sprintf("no python interpreter found in %s", env.Path), nil
+}
+
+// checkBase makes sure the base interpreter a virtual environment was created from
+// still exists, using the paths recorded in pyvenv.cfg
+func checkBase(system afero.Afero, env Environment) ([]string, error) {
+	var problems []string
+
+	if home := env.Config["home"]; home != "" {
+		exists, err := system.DirExists(home)
+		if err != nil {
+			return nil, fmt.Errorf("could not check for %s: %w", home, err)
+		}
+		if !exists {
+			problems = append(problems, fmt.Sprintf("base interpreter directory %s no longer exists", home))
+		}
+	}
+
+	if executable := env.Config["executable"]; executable != "" {
+		exists, err := system.Exists(executable)
+		if err != nil {
+			return nil, fmt.Errorf("could not check for %s: %w", executable, err)
+		}
+		if !exists {
+			problems = append(problems, fmt.Sprintf("base interpreter %s no longer exists", executable))
+		}
+	}
+
+	return problems, nil
+}
@@ -0,0 +1,138 @@
+package environment
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      Environment
+		files    []string // Files in the project
+		system   []string // Files on the rest of the system
+		requires string
+		want     []string
+	}{
+		{
+			name: "healthy",
+			env: Environment{
+				Path:    ".venv",
+				Kind:    Venv,
+				Version: "3.10.4",
+				Config:  map[string]string{"home": "/usr/local/bin", "executable": "/usr/local/bin/python3.10"},
+			},
+			files:    []string{".venv/bin/python"},
+			system:   []string{"/usr/local/bin/python3.10"},
+			requires: ">=3.8",
+			want:     nil,
+		},
+		{
+			name:  "windows layout",
+			env:   Environment{Path: ".venv", Kind: Venv},
+			files: []string{".venv/Scripts/python.exe"},
+			want:  nil,
+		},
+		{
+			name:  "missing interpreter",
+			env:   Environment{Path: ".venv", Kind: Venv},
+			files: []string{".venv/pyvenv.cfg"},
+			want:  []string{"no python interpreter found in .venv"},
+		},
+		{
+			name:  "base interpreter removed",
+			env:   Environment{Path: "env", Kind: Venv, Config: map[string]string{"home": "/opt/homebrew/Cellar/python@3.9/bin", "executable": "/opt/homebrew/Cellar/python@3.9/bin/python3.9"}},
+			files: []string{"env/bin/python"},
+			want: []string{
+				"base interpreter directory /opt/homebrew/Cellar/python@3.9/bin no longer exists",
+				"base interpreter /opt/homebrew/Cellar/python@3.9/bin/python3.9 no longer exists",
+			},
+		},
+		{
+			name:     "unparseable requires python is skipped",
+			env:      Environment{Path: ".venv", Kind: Venv, Version: "3.10.4"},
+			files:    []string{".venv/bin/python"},
+			requires: "^3.8",
+			want:     nil,
+		},
+		{
+			name:     "requires python mismatch",
+			env:      Environment{Path: ".venv", Kind: Venv, Version: "3.8.10"},
+			files:    []string{".venv/bin/python"},
+			requires: ">=3.9",
+			want:     []string{`python 3.8.10 does not satisfy requires-python ">=3.9"`},
+		},
+		{
+			name:  "conda ignores pyvenv.cfg",
+			env:   Environment{Path: ".venv", Kind: Conda, Version: "3.10.4"},
+			files: []string{".venv/bin/python"},
+			want:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			project := afero.Afero{Fs: afero.NewMemMapFs()}
+			system := afero.Afero{Fs: afero.NewMemMapFs()}
+			for _, file := range tt.files {
+				if err := project.WriteFile(file, []byte(""), 0o755); err != nil {
+					t.Fatalf("could not create file: %v", err)
+				}
+			}
+			for _, file := range tt.system {
+				if err := system.WriteFile(file, []byte(""), 0o755); err != nil {
+					t.Fatalf("could not create file: %v", err)
+				}
+			}
+
+			health, err := Check(project, system, tt.env, tt.requires)
+			if err != nil {
+				t.Fatalf("Check returned an error: %v", err)
+			}
+
+			if health.OK() != (len(tt.want) == 0) {
+				t.Errorf("OK() = %v, wanted %v", health.OK(), len(tt.want) == 0)
+			}
+			if !reflect.DeepEqual(health.Problems, tt.want) {
+				t.Errorf("got %#v, wanted %#v", health.Problems, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheck_DanglingSymlink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks need elevated privileges on windows")
+	}
+
+	tmp := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmp, ".venv", "bin"), 0o755); err != nil {
+		t.Fatalf("could not create bin dir: %v", err)
+	}
+	target := filepath.Join(tmp, "python3.9")
+	if err := os.Symlink(target, filepath.Join(tmp, ".venv", "bin", "python")); err != nil {
+		t.Fatalf("could not create symlink: %v", err)
+	}
+
+	project := afero.Afero{Fs: afero.NewBasePathFs(afero.NewOsFs(), tmp)}
+	system := afero.Afero{Fs: afero.NewOsFs()}
+
+	health, err := Check(project, system, Environment{Path: ".venv", Kind: Venv}, "")
+	if err != nil {
+		t.Fatalf("Check returned an error: %v", err)
+	}
+
+	if health.OK() {
+		t.Fatal("environment with a dangling interpreter reported as healthy")
+	}
+	want := "dangling symlink to " + target
+	if got := health.Problems[0]; !strings.Contains(got, want) {
+		t.Errorf("got %q, wanted it to contain %q", got, want)
+	}
+}
@@ -0,0 +1,148 @@
+package environment
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// operators are the PEP 440 comparison operators, longest first so that
+// e.g. "===" is not mistaken for "=="
+var operators = []string{"===", "~=", "==", "!=", "<=", ">=", "<", ">"}
+
+// Satisfies reports whether the python 'version' (e.g. "3.10.4") satisfies the
+// PEP 440 version specifier 'spec' (e.g. ">=3.8,<4"), as used by requires-python
+//
+// Only release segments are compared, pre/post/dev release and local version
+// labels are ignored which is plenty for checking python versions
+func Satisfies(version, spec string) (bool, error) {
+	have, err := parseRelease(version)
+	if err != nil {
+		return false, fmt.Errorf("bad version %q: %w", version, err)
+	}
+
+	for _, clause := range strings.Split(spec, ",") {
+		clause = strings.TrimSpace(clause)
+		if clause == "" {
+			continue
+		}
+
+		ok, err := satisfiesClause(have, clause)
+		if err != nil {
+			return false, fmt.Errorf("bad specifier %q: %w", spec, err)
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// satisfiesClause checks a version against a single specifier clause e.g. ">=3.8"
+func satisfiesClause(have []int, clause string) (bool, error) {
+	op := ""
+	for _, candidate := range operators {
+		if strings.HasPrefix(clause, candidate) {
+			op = candidate
+			break
+		}
+	}
+	if op == "" {
+		return false, fmt.Errorf("no operator in %q", clause)
+	}
+
+	target := strings.TrimSpace(strings.TrimPrefix(clause, op))
+	wildcard := strings.HasSuffix(target, ".*")
+	want, err := parseRelease(strings.TrimSuffix(target, ".*"))
+	if err != nil {
+		return false, err
+	}
+
+	switch op {
+	case "==", "===":
+		if wildcard {
+			return hasPrefix(have, want), nil
+		}
+		return compare(have, want) == 0, nil
+	case "!=":
+		if wildcard {
+			return !hasPrefix(have, want), nil
+		}
+		return compare(have, want) != 0, nil
+	case "~=":
+		// ~=3.8.1 means >=3.8.1 and ==3.8.*
+		if len(want) < 2 {
+			return false, fmt.Errorf("~= needs at least two release segments: %q", clause)
+		}
+		return compare(have, want) >= 0 && hasPrefix(have, want[:len(want)-1]), nil
+	case "<=":
+		return compare(have, want) <= 0, nil
+	case ">=":
+		return compare(have, want) >= 0, nil
+	case "<":
+		return compare(have, want) < 0, nil
+	default: // ">"
+		return compare(have, want) > 0, nil
+	}
+}
+
+// parseRelease parses the release segments of a version e.g. "3.10.4rc1" -> [3 10 4]
+func parseRelease(version string) ([]int, error) {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if i := strings.IndexFunc(version, func(r rune) bool { return (r < '0' || r > '9') && r != '.' }); i != -1 {
+		version = version[:i]
+	}
+	version = strings.TrimSuffix(version, ".")
+	if version == "" {
+		return nil, fmt.Errorf("no release segments")
+	}
+
+	parts := strings.Split(version, ".")
+	release := make([]int, 0, len(parts))
+	for _, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("%w", err)
+		}
+		release = append(release, n)
+	}
+
+	return release, nil
+}
+
+// compare compares two releases, padding the shorter with zeros
+func compare(a, b []int) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var x, y int
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+
+	return 0
+}
+
+// hasPrefix reports whether release 'a' starts with the segments in 'prefix'
+func hasPrefix(a, prefix []int) bool {
+	for i, n := range prefix {
+		var x int
+		if i < len(a) {
+			x = a[i]
+		}
+		if x != n {
+			return false
+		}
+	}
+
+	return true
+}
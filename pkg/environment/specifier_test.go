@@ -0,0 +1,44 @@
+package environment
+
+import "testing"
+
+func TestSatisfies(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		spec    string
+		want    bool
+		wantErr bool
+	}{
+		{name: "empty", version: "3.10.4", spec: "", want: true},
+		{name: "greater equal", version: "3.10.4", spec: ">=3.8", want: true},
+		{name: "greater equal fails", version: "3.7.12", spec: ">=3.8", want: false},
+		{name: "range", version: "3.10.4", spec: ">=3.8, <4", want: true},
+		{name: "range upper", version: "3.12.0", spec: ">=3.8,<3.12", want: false},
+		{name: "equal padded", version: "3.10.0", spec: "==3.10", want: true},
+		{name: "wildcard", version: "3.10.4", spec: "==3.10.*", want: true},
+		{name: "wildcard fails", version: "3.11.1", spec: "==3.10.*", want: false},
+		{name: "not equal wildcard", version: "3.11.1", spec: "!=3.10.*", want: true},
+		{name: "compatible", version: "3.9.1", spec: "~=3.8", want: true},
+		{name: "compatible fails", version: "4.0.0", spec: "~=3.8", want: false},
+		{name: "compatible patch", version: "3.8.9", spec: "~=3.8.2", want: true},
+		{name: "compatible patch fails", version: "3.9.0", spec: "~=3.8.2", want: false},
+		{name: "greater", version: "3.10.4", spec: ">3.10", want: true},
+		{name: "less equal", version: "3.10.4", spec: "<=3.10.4", want: true},
+		{name: "arbitrary", version: "3.10.4", spec: "===3.10.4", want: true},
+		{name: "bad version", version: "three", spec: ">=3.8", wantErr: true},
+		{name: "bad specifier", version: "3.10.4", spec: "3.8", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Satisfies(tt.version, tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Satisfies() err = %v, wantErr = %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Satisfies(%q, %q) = %v, wanted %v", tt.version, tt.spec, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+// Package fingerprint works out whether a project is managed by a particular tool
+// (poetry, flit etc.) by weighing up all the evidence in the project together
+//
+// A project can name a tool in a number of places: it's build-backend (which
+// has changed name over the years), [build-system].requires, a [tool.<name>] table
+// or a lock file, and any one of these on it's own can be misleading
+package fingerprint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/FollowTheProcess/venv/pkg/project"
+	"github.com/FollowTheProcess/venv/pkg/pyproject"
+	"github.com/spf13/afero"
+)
+
+// Tool describes everything that marks a project as managed by a tool
+type Tool struct {
+	Name      string   // The tool's name e.g. "poetry"
+	Backends  []string // Every build-backend the tool has ever used e.g. "poetry.core.masonry.api"
+	Requires  []string // Distribution names in [build-system].requires e.g. "poetry-core"
+	Tables    []string // Dotted paths to tables under [tool] e.g. "poetry" or "flit.metadata"
+	LockFiles []string // Lock files the tool writes e.g. "poetry.lock"
+}
+
+// Verdict is the result of fingerprinting a project for a Tool
+type Verdict struct {
+	Evidence   []string           // Everything found that points to the tool
+	Confidence project.Confidence // How sure we are the project is managed by the tool
+	Backend    bool               // Whether the tool is also the project's build backend
+}
+
+// Fingerprint inspects the project at the root of 'fs' along with it's parsed pyproject.toml
+// 'py' and returns the verdict on whether it is managed by 'tool', a project without
+// a pyproject.toml never is
+//
+// Naming the tool as the build-backend, or having both it's [tool] table and lock file
+// is High confidence. A [tool] table (e.g. using poetry only to manage dependencies with
+// a different backend) or the tool in [build-system].requires on it's own is Medium,
+// and a lock file on it's own is Low
+func Fingerprint(fs afero.Afero, py *pyproject.PyProject, tool Tool) (Verdict, error) {
+	if py == nil {
+		// A stray lock file is not enough to go on
+		return Verdict{}, nil
+	}
+
+	locked, err := lockFiles(fs, tool)
+	if err != nil {
+		return Verdict{}, fmt.Errorf("%w", err)
+	}
+
+	var verdict Verdict
+
+	backend := py.Backend()
+	if contains(tool.Backends, backend) {
+		verdict.Backend = true
+		verdict.Evidence = append(verdict.Evidence, fmt.Sprintf("%s build-backend is %q", pyproject.File, backend))
+	}
+
+	var required bool
+	for _, req := range py.Requires() {
+		if contains(tool.Requires, RequirementName(req)) {
+			required = true
+			verdict.Evidence = append(verdict.Evidence, fmt.Sprintf("%s [build-system] requires %q", pyproject.File, req))
+		}
+	}
+
+	var tabled bool
+	for _, table := range tool.Tables {
+		if py.HasTool(table) {
+			tabled = true
+			verdict.Evidence = append(verdict.Evidence, fmt.Sprintf("%s has a [tool.%s] table", pyproject.File, table))
+		}
+	}
+
+	for _, lock := range locked {
+		verdict.Evidence = append(verdict.Evidence, fmt.Sprintf("found %s", lock))
+	}
+
+	switch {
+	case verdict.Backend, tabled && len(locked) != 0:
+		verdict.Confidence = project.High
+	case tabled, required:
+		verdict.Confidence = project.Medium
+	case len(locked) != 0:
+		verdict.Confidence = project.Low
+	}
+
+	if verdict.Confidence > project.None && backend != "" && !verdict.Backend {
+		verdict.Evidence = append(verdict.Evidence, fmt.Sprintf("but the build-backend is %q", backend))
+	}
+
+	return verdict, nil
+}
+
+// RequirementName returns the normalised distribution name from a PEP 508
+// requirement e.g. "Poetry_Core>=1.0.0" is "poetry-core"
+func RequirementName(req string) string {
+	end := strings.IndexAny(req, "<>=!~;[( @")
+	if end != -1 {
+		req = req[:end]
+	}
+
+	req = strings.ToLower(strings.TrimSpace(req))
+	return strings.NewReplacer("_", "-", ".", "-").Replace(req)
+}
+
+// lockFiles returns those of the tool's lock files present in the project
+func lockFiles(fs afero.Afero, tool Tool) ([]string, error) {
+	var found []string
+	for _, lock := range tool.LockFiles {
+		exists, err := fs.Exists(lock)
+		if err != nil {
+			return nil, fmt.Errorf("could not check for %s: %w", lock, err)
+		}
+		if exists {
+			found = append(found, lock)
+		}
+	}
+	sort.Strings(found)
+
+	return found, nil
+}
+
+// contains reports whether 'items' contains 'item'
+func contains(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,156 @@
+package fingerprint
+
+import (
+	"testing"
+
+	"github.com/FollowTheProcess/venv/pkg/project"
+	"github.com/FollowTheProcess/venv/pkg/pyproject"
+	"github.com/spf13/afero"
+)
+
+var poetry = Tool{
+	Name:      "poetry",
+	Backends:  []string{"poetry.core.masonry.api", "poetry.masonry.api"},
+	Requires:  []string{"poetry-core", "poetry"},
+	Tables:    []string{"poetry"},
+	LockFiles: []string{"poetry.lock"},
+}
+
+func TestFingerprint(t *testing.T) {
+	tests := []struct {
+		files      map[string]string
+		name       string
+		evidence   int
+		confidence project.Confidence
+		backend    bool
+	}{
+		{
+			name:       "nothing",
+			files:      map[string]string{},
+			confidence: project.None,
+		},
+		{
+			name:       "lock file without pyproject",
+			files:      map[string]string{"poetry.lock": ""},
+			confidence: project.None,
+		},
+		{
+			name: "modern backend",
+			files: map[string]string{
+				"pyproject.toml": "[build-system]\nrequires = [\"poetry-core>=1.0.0\"]\nbuild-backend = \"poetry.core.masonry.api\"\n",
+			},
+			evidence:   2,
+			confidence: project.High,
+			backend:    true,
+		},
+		{
+			name: "legacy backend",
+			files: map[string]string{
+				"pyproject.toml": "[build-system]\nrequires = [\"poetry>=0.12\"]\nbuild-backend = \"poetry.masonry.api\"\n",
+			},
+			evidence:   2,
+			confidence: project.High,
+			backend:    true,
+		},
+		{
+			name: "tool table and lock with another backend",
+			files: map[string]string{
+				"pyproject.toml": "[build-system]\nrequires = [\"hatchling\"]\nbuild-backend = \"hatchling.build\"\n\n[tool.poetry]\nname = \"demo\"\n",
+				"poetry.lock":    "",
+			},
+			evidence:   3,
+			confidence: project.High,
+		},
+		{
+			name: "tool table only",
+			files: map[string]string{
+				"pyproject.toml": "[tool.poetry]\nname = \"demo\"\n",
+			},
+			evidence:   1,
+			confidence: project.Medium,
+		},
+		{
+			name: "nested tool table",
+			files: map[string]string{
+				"pyproject.toml": "[tool.poetry.dependencies]\npython = \"^3.10\"\n",
+			},
+			evidence:   1,
+			confidence: project.Medium,
+		},
+		{
+			name: "requires only",
+			files: map[string]string{
+				"pyproject.toml": "[build-system]\nrequires = [\"Poetry_Core\"]\n",
+			},
+			evidence:   1,
+			confidence: project.Medium,
+		},
+		{
+			name: "lock file only",
+			files: map[string]string{
+				"pyproject.toml": "[project]\nname = \"demo\"\n",
+				"poetry.lock":    "",
+			},
+			evidence:   1,
+			confidence: project.Low,
+		},
+		{
+			name: "something else",
+			files: map[string]string{
+				"pyproject.toml": "[build-system]\nrequires = [\"setuptools\"]\nbuild-backend = \"setuptools.build_meta\"\n\n[tool.black]\nline-length = 88\n",
+			},
+			confidence: project.None,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			af := afero.Afero{Fs: afero.NewMemMapFs()}
+			for file, content := range tt.files {
+				if err := af.WriteFile(file, []byte(content), 0o755); err != nil {
+					t.Fatalf("could not create file: %v", err)
+				}
+			}
+
+			py, err := pyproject.Read(af)
+			if err != nil {
+				t.Fatalf("could not read pyproject.toml: %v", err)
+			}
+
+			got, err := Fingerprint(af, py, poetry)
+			if err != nil {
+				t.Fatalf("Fingerprint returned an error: %v", err)
+			}
+
+			if got.Confidence != tt.confidence {
+				t.Errorf("got confidence %v, wanted %v (evidence %v)", got.Confidence, tt.confidence, got.Evidence)
+			}
+
+			if got.Backend != tt.backend {
+				t.Errorf("got backend %v, wanted %v", got.Backend, tt.backend)
+			}
+
+			// A different backend is also reported as evidence
+			if len(got.Evidence) < tt.evidence {
+				t.Errorf("got evidence %v, wanted at least %d items", got.Evidence, tt.evidence)
+			}
+		})
+	}
+}
+
+func TestRequirementName(t *testing.T) {
+	tests := map[string]string{
+		"poetry-core>=1.0.0":                     "poetry-core",
+		"Flit_Core >=3.2,<4":                     "flit-core",
+		"flit":                                   "flit",
+		"zope.interface":                         "zope-interface",
+		"setuptools[core]; python_version>'3.8'": "setuptools",
+		"pkg @ https://example.com/pkg.whl":      "pkg",
+	}
+
+	for req, want := range tests {
+		if got := RequirementName(req); got != want {
+			t.Errorf("RequirementName(%q) = %q, wanted %q", req, got, want)
+		}
+	}
+}
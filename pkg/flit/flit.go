@@ -6,20 +6,31 @@ import (
 	"io"
 	"os/exec"
 
-	"github.com/pelletier/go-toml/v2"
+	"github.com/FollowTheProcess/venv/pkg/fingerprint"
+	"github.com/FollowTheProcess/venv/pkg/project"
+	"github.com/FollowTheProcess/venv/pkg/pyproject"
+	"github.com/FollowTheProcess/venv/pkg/python"
 	"github.com/spf13/afero"
 )
 
-var flitCommand = exec.Command
+// flitCommand and lookPath are internal reassignments of their exec
+// equivalents used for mocking during tests
+var (
+	flitCommand = exec.Command
+	lookPath    = exec.LookPath
+)
 
-// If the build-backend says this, it's a valid flit project
-const flitMarker = "flit.buildapi"
+// installArgs are the arguments to "flit install" that install the project
+// and it's development dependencies into the virtual environment
+var installArgs = []string{"install", "--deps", "develop", "--symlink", "--python", ".venv/bin/python"}
 
-type pyProjectTOML struct {
-	BuildSystem struct {
-		Requires     []string `toml:"requires"`
-		BuildBackend string   `toml:"build-backend"`
-	} `toml:"build-system"`
+// tool is everything that marks a project as managed by flit, modern projects use
+// the flit_core.buildapi backend, older ones the flit.buildapi from flit itself
+var tool = fingerprint.Tool{
+	Name:     "flit",
+	Backends: []string{"flit_core.buildapi", "flit.buildapi"},
+	Requires: []string{"flit-core", "flit"},
+	Tables:   []string{"flit"},
 }
 
 // newFlitCmd returns an exec.Cmd configured with the parameters passed in
@@ -32,9 +43,10 @@ func newFlitCommand(cwd string, stdout, stderr io.Writer, args []string) *exec.C
 	return cmd
 }
 
-// Install calls flit install
+// Install calls flit install, using the flit on $PATH to install
+// into the virtual environment
 func Install(cwd string, stdout, stderr io.Writer) error {
-	cmd := newFlitCommand(cwd, stdout, stderr, []string{"install", "--deps", "develop", "--symlink", "--python", ".venv/bin/python"})
+	cmd := newFlitCommand(cwd, stdout, stderr, installArgs)
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("could not create flit environment: %w", err)
 	}
@@ -42,19 +54,80 @@ func Install(cwd string, stdout, stderr io.Writer) error {
 	return nil
 }
 
-// IsFlitFile reads the contents of the toml file given by 'path' and
-// determines if this is a valid flit pyproject.toml file
-func IsFlitFile(af afero.Afero, path string) (bool, error) {
-	var pyToml pyProjectTOML
+// Bootstrap installs flit itself into the virtual environment, for when
+// it isn't on $PATH
+func Bootstrap(cwd string, stdout, stderr io.Writer) error {
+	if err := python.Install(cwd, stdout, stderr, []string{"flit"}); err != nil {
+		return fmt.Errorf("could not install flit: %w", err)
+	}
+
+	return nil
+}
+
+// InstallFromVenv calls flit install using the flit installed in the virtual
+// environment by Bootstrap
+func InstallFromVenv(cwd string, stdout, stderr io.Writer) error {
+	if err := python.RunModule(cwd, stdout, stderr, "flit", installArgs); err != nil {
+		return fmt.Errorf("could not create flit environment: %w", err)
+	}
+
+	return nil
+}
+
+// IsFlitProject reports whether the project's build-backend is flit, see
+// Detector for projects using flit with a different build-backend
+func IsFlitProject(py *pyproject.PyProject) bool {
+	for _, backend := range tool.Backends {
+		if py.Backend() == backend {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Detector recognises projects managed by flit, whether it's their build-backend
+// or just used as a tool
+type Detector struct{}
 
-	data, err := af.ReadFile(path)
+// Name implements project.Detector
+func (Detector) Name() string {
+	return "flit"
+}
+
+// Detect implements project.Detector
+func (Detector) Detect(fs afero.Afero, py *pyproject.PyProject) (project.Match, error) {
+	verdict, err := fingerprint.Fingerprint(fs, py, tool)
 	if err != nil {
-		return false, fmt.Errorf("could not read %s: %w", path, err)
+		return project.Match{}, fmt.Errorf("%w", err)
+	}
+	if verdict.Confidence == project.None {
+		return project.Match{}, nil
 	}
 
-	if err := toml.Unmarshal(data, &pyToml); err != nil {
-		return false, fmt.Errorf("could not unmarshall toml data: %w", err)
+	match := project.Match{
+		Confidence: verdict.Confidence,
+		Reasons:    verdict.Evidence,
+		Plan: project.Plan{
+			Summary: fmt.Sprintf("Found %q specifying flit. Installing...", pyproject.File),
+			Steps: []project.Step{
+				{Description: "create virtual environment", Run: python.CreateVenv, Phase: project.Build},
+				{Description: "update seed packages", Run: python.UpdateSeeds},
+			},
+		},
 	}
 
-	return pyToml.BuildSystem.BuildBackend == flitMarker, nil
+	if _, err := lookPath("flit"); err == nil {
+		match.Plan.Steps = append(match.Plan.Steps, project.Step{Description: "flit install", Run: Install})
+		return match, nil
+	}
+
+	// No flit on $PATH, so install it into the environment and run it from there
+	match.Reasons = append(match.Reasons, "flit is not installed, installing it into the virtual environment")
+	match.Plan.Steps = append(match.Plan.Steps,
+		project.Step{Description: "pip install flit", Run: Bootstrap},
+		project.Step{Description: "python -m flit install", Run: InstallFromVenv},
+	)
+
+	return match, nil
 }
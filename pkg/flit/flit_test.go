@@ -7,6 +7,8 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/FollowTheProcess/venv/pkg/project"
+	"github.com/FollowTheProcess/venv/pkg/pyproject"
 	"github.com/spf13/afero"
 )
 
@@ -121,7 +123,7 @@ func TestInstall(t *testing.T) {
 	}
 }
 
-func TestIsflitFile(t *testing.T) {
+func TestIsFlitProject(t *testing.T) {
 	t.Run("true if content is there", func(t *testing.T) {
 		af := afero.Afero{Fs: afero.NewMemMapFs()}
 
@@ -135,11 +137,13 @@ func TestIsflitFile(t *testing.T) {
 			t.Fatalf("could not create file: %v", err)
 		}
 
-		got, err := IsFlitFile(af, "pyproject.toml")
+		py, err := pyproject.Read(af)
 		if err != nil {
-			t.Errorf("IsFlitFile returned an error: %v", err)
+			t.Fatalf("could not read pyproject.toml: %v", err)
 		}
 
+		got := IsFlitProject(py)
+
 		if got != true {
 			t.Errorf("got %v, wanted true", got)
 		}
@@ -158,11 +162,13 @@ func TestIsflitFile(t *testing.T) {
 			t.Fatalf("could not create file: %v", err)
 		}
 
-		got, err := IsFlitFile(af, "pyproject.toml")
+		py, err := pyproject.Read(af)
 		if err != nil {
-			t.Errorf("IsFlitFile returned an error: %v", err)
+			t.Fatalf("could not read pyproject.toml: %v", err)
 		}
 
+		got := IsFlitProject(py)
+
 		if got != false {
 			t.Errorf("got %v, wanted false", got)
 		}
@@ -178,13 +184,128 @@ func TestIsflitFile(t *testing.T) {
 			t.Fatalf("could not create file: %v", err)
 		}
 
-		got, err := IsFlitFile(af, "pyproject.toml")
+		py, err := pyproject.Read(af)
 		if err != nil {
-			t.Errorf("IsFlitFile returned an error: %v", err)
+			t.Fatalf("could not read pyproject.toml: %v", err)
 		}
 
+		got := IsFlitProject(py)
+
 		if got != false {
 			t.Errorf("got %v, wanted false", got)
 		}
 	})
 }
+
+func TestDetector_Detect(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		confidence project.Confidence
+		write      bool
+	}{
+		{
+			name:       "no pyproject.toml",
+			write:      false,
+			confidence: project.None,
+		},
+		{
+			name:       "flit pyproject.toml",
+			content:    "[build-system]\nbuild-backend = \"flit.buildapi\"\n",
+			write:      true,
+			confidence: project.High,
+		},
+		{
+			name:       "flit_core pyproject.toml",
+			content:    "[build-system]\nrequires = [\"flit_core >=3.2,<4\"]\nbuild-backend = \"flit_core.buildapi\"\n",
+			write:      true,
+			confidence: project.High,
+		},
+		{
+			name:       "other pyproject.toml",
+			content:    "[build-system]\nbuild-backend = \"something else\"\n",
+			write:      true,
+			confidence: project.None,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			af := afero.Afero{Fs: afero.NewMemMapFs()}
+			if tt.write {
+				if err := af.WriteFile("pyproject.toml", []byte(tt.content), 0o755); err != nil {
+					t.Fatalf("could not create file: %v", err)
+				}
+			}
+
+			py, err := pyproject.Read(af)
+			if err != nil {
+				t.Fatalf("could not read pyproject.toml: %v", err)
+			}
+
+			got, err := Detector{}.Detect(af, py)
+			if err != nil {
+				t.Fatalf("Detect() returned an error: %v", err)
+			}
+
+			if got.Confidence != tt.confidence {
+				t.Errorf("got confidence %v, wanted %v", got.Confidence, tt.confidence)
+			}
+		})
+	}
+}
+
+func TestDetector_Plan(t *testing.T) {
+	tests := []struct {
+		lookPath func(file string) (string, error)
+		name     string
+		want     []string
+	}{
+		{
+			name:     "flit installed",
+			lookPath: func(file string) (string, error) { return "/usr/bin/" + file, nil },
+			want:     []string{"create virtual environment", "update seed packages", "flit install"},
+		},
+		{
+			name:     "flit not installed",
+			lookPath: func(file string) (string, error) { return "", exec.ErrNotFound },
+			want:     []string{"create virtual environment", "update seed packages", "pip install flit", "python -m flit install"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lookPath = tt.lookPath
+			defer func() { lookPath = exec.LookPath }()
+
+			af := afero.Afero{Fs: afero.NewMemMapFs()}
+			content := "[build-system]\nbuild-backend = \"flit_core.buildapi\"\n"
+			if err := af.WriteFile("pyproject.toml", []byte(content), 0o755); err != nil {
+				t.Fatalf("could not create file: %v", err)
+			}
+
+			py, err := pyproject.Read(af)
+			if err != nil {
+				t.Fatalf("could not read pyproject.toml: %v", err)
+			}
+
+			match, err := Detector{}.Detect(af, py)
+			if err != nil {
+				t.Fatalf("Detect() returned an error: %v", err)
+			}
+
+			var got []string
+			for _, step := range match.Plan.Steps {
+				got = append(got, step.Description)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got steps %v, wanted %v", got, tt.want)
+			}
+
+			if match.Plan.Steps[0].Phase != project.Build {
+				t.Error("creating the virtual environment should only happen on a build")
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+// Package hatch implements support for projects built with hatch (hatchling)
+package hatch
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/FollowTheProcess/venv/pkg/project"
+	"github.com/FollowTheProcess/venv/pkg/pyproject"
+	"github.com/FollowTheProcess/venv/pkg/python"
+	"github.com/spf13/afero"
+)
+
+// If the build-backend says this, it's a valid hatch project
+const hatchMarker = "hatchling.build"
+
+// defaultEnvTable is where the default hatch environment is declared, under [tool]
+const defaultEnvTable = "hatch.envs.default"
+
+// Env is a hatch environment as declared under [tool.hatch.envs.<name>]
+type Env struct {
+	Dependencies []string `toml:"dependencies"` // Extra dependencies installed into the environment
+	Features     []string `toml:"features"`     // Project extras installed into the environment
+}
+
+// InstallArgs returns the arguments to pip install to install the project
+// editable along with everything the environment asks for
+func (e Env) InstallArgs() []string {
+	target := "."
+	if len(e.Features) != 0 {
+		target = fmt.Sprintf(".[%s]", strings.Join(e.Features, ","))
+	}
+
+	args := []string{"-e", target}
+	args = append(args, e.Dependencies...)
+
+	return args
+}
+
+// Install installs the project editable into the virtual environment in cwd
+// along with the dependencies and features of the hatch environment 'env'
+func Install(cwd string, stdout, stderr io.Writer, env Env) error {
+	if err := python.Install(cwd, stdout, stderr, env.InstallArgs()); err != nil {
+		return fmt.Errorf("could not install hatch project: %w", err)
+	}
+
+	return nil
+}
+
+// IsHatchProject reports whether the project's build-backend is hatchling
+func IsHatchProject(py *pyproject.PyProject) bool {
+	return py.Backend() == hatchMarker
+}
+
+// DefaultEnv returns the hatch default environment declared under
+// [tool.hatch.envs.default] in the project's pyproject.toml
+func DefaultEnv(py *pyproject.PyProject) (Env, error) {
+	var env Env
+	if err := py.DecodeTool(defaultEnvTable, &env); err != nil {
+		return Env{}, fmt.Errorf("%w", err)
+	}
+
+	return env, nil
+}
+
+// Detector recognises projects whose pyproject.toml specifies hatchling
+type Detector struct{}
+
+// Name implements project.Detector
+func (Detector) Name() string {
+	return "hatch"
+}
+
+// Detect implements project.Detector
+func (Detector) Detect(fs afero.Afero, py *pyproject.PyProject) (project.Match, error) {
+	if !IsHatchProject(py) {
+		return project.Match{}, nil
+	}
+
+	env, err := DefaultEnv(py)
+	if err != nil {
+		return project.Match{}, fmt.Errorf("%w", err)
+	}
+
+	reasons := []string{fmt.Sprintf("%s build-backend is %q", pyproject.File, hatchMarker)}
+	if py.HasTool(defaultEnvTable) {
+		reasons = append(reasons, fmt.Sprintf("found [tool.%s]", defaultEnvTable))
+	}
+
+	return project.Match{
+		Confidence: project.High,
+		Reasons:    reasons,
+		Plan: project.Plan{
+			Summary: fmt.Sprintf("Found %q specifying hatch. Creating virtual environment and installing dependencies", pyproject.File),
+			Steps: []project.Step{
+				{Description: "create virtual environment", Run: python.CreateVenv, Phase: project.Build},
+				{Description: "update seed packages", Run: python.UpdateSeeds},
+				{
+					Description: fmt.Sprintf("pip install %v", env.InstallArgs()),
+					Run: func(cwd string, stdout, stderr io.Writer) error {
+						return Install(cwd, stdout, stderr, env)
+					},
+				},
+			},
+		},
+	}, nil
+}
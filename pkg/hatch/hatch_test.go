@@ -0,0 +1,166 @@
+package hatch
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/FollowTheProcess/venv/pkg/project"
+	"github.com/FollowTheProcess/venv/pkg/pyproject"
+	"github.com/spf13/afero"
+)
+
+const hatchContent = `[build-system]
+requires = ["hatchling"]
+build-backend = "hatchling.build"
+
+[project]
+name = "demo"
+optional-dependencies = { cli = ["click"] }
+
+[tool.hatch.envs.default]
+dependencies = ["pytest", "mypy"]
+features = ["cli"]
+`
+
+func TestIsHatchProject(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    bool
+	}{
+		{
+			name:    "true if content is there",
+			content: hatchContent,
+			want:    true,
+		},
+		{
+			name:    "false if content is not there",
+			content: "[build-system]\nrequires = [\"flit_core\"]\nbuild-backend = \"flit_core.buildapi\"\n",
+			want:    false,
+		},
+		{
+			name:    "false if content is not even close",
+			content: `something = "toml"`,
+			want:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			af := afero.Afero{Fs: afero.NewMemMapFs()}
+			if err := af.WriteFile("pyproject.toml", []byte(tt.content), 0o755); err != nil {
+				t.Fatalf("could not create file: %v", err)
+			}
+
+			py, err := pyproject.Read(af)
+			if err != nil {
+				t.Fatalf("could not read pyproject.toml: %v", err)
+			}
+
+			got := IsHatchProject(py)
+
+			if got != tt.want {
+				t.Errorf("got %v, wanted %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultEnv(t *testing.T) {
+	af := afero.Afero{Fs: afero.NewMemMapFs()}
+	if err := af.WriteFile("pyproject.toml", []byte(hatchContent), 0o755); err != nil {
+		t.Fatalf("could not create file: %v", err)
+	}
+
+	py, err := pyproject.Read(af)
+	if err != nil {
+		t.Fatalf("could not read pyproject.toml: %v", err)
+	}
+
+	got, err := DefaultEnv(py)
+	if err != nil {
+		t.Fatalf("DefaultEnv returned an error: %v", err)
+	}
+
+	want := Env{Dependencies: []string{"pytest", "mypy"}, Features: []string{"cli"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, wanted %#v", got, want)
+	}
+}
+
+func TestEnv_InstallArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		env  Env
+		want []string
+	}{
+		{
+			name: "empty",
+			env:  Env{},
+			want: []string{"-e", "."},
+		},
+		{
+			name: "features",
+			env:  Env{Features: []string{"cli", "docs"}},
+			want: []string{"-e", ".[cli,docs]"},
+		},
+		{
+			name: "dependencies and features",
+			env:  Env{Dependencies: []string{"pytest"}, Features: []string{"cli"}},
+			want: []string{"-e", ".[cli]", "pytest"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.env.InstallArgs(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, wanted %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetector_Detect(t *testing.T) {
+	t.Run("no pyproject.toml", func(t *testing.T) {
+		af := afero.Afero{Fs: afero.NewMemMapFs()}
+
+		py, err := pyproject.Read(af)
+		if err != nil {
+			t.Fatalf("could not read pyproject.toml: %v", err)
+		}
+
+		got, err := Detector{}.Detect(af, py)
+		if err != nil {
+			t.Fatalf("Detect() returned an error: %v", err)
+		}
+
+		if got.Found() {
+			t.Errorf("Detect() matched an empty directory: %#v", got)
+		}
+	})
+
+	t.Run("hatch pyproject.toml", func(t *testing.T) {
+		af := afero.Afero{Fs: afero.NewMemMapFs()}
+		if err := af.WriteFile("pyproject.toml", []byte(hatchContent), 0o755); err != nil {
+			t.Fatalf("could not create file: %v", err)
+		}
+
+		py, err := pyproject.Read(af)
+		if err != nil {
+			t.Fatalf("could not read pyproject.toml: %v", err)
+		}
+
+		got, err := Detector{}.Detect(af, py)
+		if err != nil {
+			t.Fatalf("Detect() returned an error: %v", err)
+		}
+
+		if got.Confidence != project.High {
+			t.Errorf("got confidence %v, wanted %v", got.Confidence, project.High)
+		}
+
+		if len(got.Plan.Steps) != 3 {
+			t.Errorf("wrong number of steps in plan: got %d, wanted 3", len(got.Plan.Steps))
+		}
+	})
+}
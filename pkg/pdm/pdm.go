@@ -0,0 +1,152 @@
+// Package pdm implements wrapper functions around pdm commands
+package pdm
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+
+	"github.com/FollowTheProcess/venv/pkg/project"
+	"github.com/FollowTheProcess/venv/pkg/pyproject"
+	"github.com/spf13/afero"
+)
+
+var pdmCommand = exec.Command
+
+const lockFile = "pdm.lock"
+
+// If the build-backend says any of these, it's a valid pdm project
+var pdmMarkers = []string{"pdm.backend", "pdm.pep517.api"}
+
+// inProjectEnv tells pdm to create it's virtual environment as .venv in the project
+const inProjectEnv = "PDM_VENV_IN_PROJECT=true"
+
+// pdmTool is the part of [tool.pdm] venv needs
+type pdmTool struct {
+	DevDependencies map[string][]string `toml:"dev-dependencies"`
+}
+
+// newPDMCommand returns an exec.Cmd configured with the parameters passed in
+func newPDMCommand(cwd string, stdout, stderr io.Writer, args []string) *exec.Cmd {
+	cmd := pdmCommand("pdm", args...)
+	cmd.Dir = cwd
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	// A nil Env means inherit ours, so make that explicit before adding to it
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env, inProjectEnv)
+
+	return cmd
+}
+
+// Install calls pdm install, making sure the environment is created in the project
+// and installing each of the dev dependency groups in 'groups'
+func Install(cwd string, stdout, stderr io.Writer, groups []string) error {
+	args := []string{"install"}
+	for _, group := range groups {
+		args = append(args, "--group", group)
+	}
+
+	cmd := newPDMCommand(cwd, stdout, stderr, args)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("could not create pdm environment: %w", err)
+	}
+
+	return nil
+}
+
+// IsPDMProject reports whether the project's build-backend is one of pdm's
+func IsPDMProject(py *pyproject.PyProject) bool {
+	for _, marker := range pdmMarkers {
+		if py.Backend() == marker {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DevGroups returns the names of the dev dependency groups under
+// [tool.pdm.dev-dependencies] in the project's pyproject.toml, sorted
+func DevGroups(py *pyproject.PyProject) ([]string, error) {
+	var tool pdmTool
+	if err := py.DecodeTool("pdm", &tool); err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	groups := make([]string, 0, len(tool.DevDependencies))
+	for group := range tool.DevDependencies {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+
+	return groups, nil
+}
+
+// Detector recognises projects managed by pdm
+type Detector struct{}
+
+// Name implements project.Detector
+func (Detector) Name() string {
+	return "pdm"
+}
+
+// Detect implements project.Detector
+func (Detector) Detect(fs afero.Afero, py *pyproject.PyProject) (project.Match, error) {
+	if py == nil {
+		return project.Match{}, nil
+	}
+
+	hasLock, err := fs.Exists(lockFile)
+	if err != nil {
+		return project.Match{}, fmt.Errorf("could not check for %s: %w", lockFile, err)
+	}
+
+	confidence := project.None
+	var reasons []string
+	if IsPDMProject(py) {
+		confidence = project.High
+		reasons = append(reasons, fmt.Sprintf("%s build-backend is %q", pyproject.File, py.Backend()))
+	}
+	if hasLock {
+		confidence = project.High
+		reasons = append(reasons, fmt.Sprintf("found %s", lockFile))
+	}
+	if py.HasTool("pdm") {
+		if confidence < project.Medium {
+			confidence = project.Medium
+		}
+		reasons = append(reasons, "found [tool.pdm]")
+	}
+
+	if confidence == project.None {
+		return project.Match{}, nil
+	}
+
+	groups, err := DevGroups(py)
+	if err != nil {
+		return project.Match{}, fmt.Errorf("%w", err)
+	}
+
+	return project.Match{
+		Confidence: confidence,
+		Reasons:    reasons,
+		Plan: project.Plan{
+			Summary: fmt.Sprintf("Found %q specifying pdm. Installing...", pyproject.File),
+			Steps: []project.Step{
+				{
+					Description: fmt.Sprintf("pdm install (dev groups: %v)", groups),
+					Run: func(cwd string, stdout, stderr io.Writer) error {
+						return Install(cwd, stdout, stderr, groups)
+					},
+				},
+			},
+			Tools: []string{"pdm"},
+		},
+	}, nil
+}
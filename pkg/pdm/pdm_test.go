@@ -0,0 +1,279 @@
+package pdm
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"reflect"
+	"testing"
+
+	"github.com/FollowTheProcess/venv/pkg/project"
+	"github.com/FollowTheProcess/venv/pkg/pyproject"
+	"github.com/spf13/afero"
+)
+
+// testCase is used as an env var to pass around so our test helper
+// knows what condition to test for
+var testCase string
+
+// extractCmdArgs is a helper for TestHelperProcess which teases out the desired
+// external command arguments from the special ones required to make go test use the
+// helper process
+func extractCmdArgs(args []string) []string {
+	for len(args) > 0 {
+		if args[0] == "--" {
+			args = args[1:]
+			break
+		}
+		args = args[1:]
+	}
+	return args
+}
+
+// assertCorrectArgs compares external command arguments to verify correctness
+// designed to be used inside the external command TestHelperProcess
+func assertCorrectArgs(expected, args []string) {
+	if !reflect.DeepEqual(args, expected) {
+		fmt.Fprintf(os.Stderr, "Error: expected cmd %#v, got %#v", expected, args)
+		os.Exit(1)
+	}
+}
+
+// fakeExecCommand is a helper that creates a fake external command
+// It does some clever magic and uses the way go test runs to insert itself
+// during a test in place of an actual command
+// it's used in the std lib to test exec
+// see: https://npf.io/2015/06/testing-exec-command/
+func fakeExecCommand(command string, args ...string) *exec.Cmd {
+	cs := []string{"-test.run=TestPDMHelperProcess", "--", command}
+	cs = append(cs, args...)
+
+	cmd := exec.Command(os.Args[0], cs...)
+	// By passing env variables like this, we can control the behaviour of our
+	// mocked command
+	// For example, have it return a non-zero exit code etc.
+	tc := "PDM_TEST_CASE=" + testCase
+	cmd.Env = []string{"GO_WANT_PDM_HELPER_PROCESS=1", tc}
+	return cmd
+}
+
+func setUp(testcase string) {
+	pdmCommand = fakeExecCommand
+	testCase = testcase
+}
+
+func tearDown() {
+	pdmCommand = exec.Command
+}
+
+// This is the main helper process for external command tests. It first checks whether or not go test wants to use it
+// by looking for the GO_WANT_HELPER_PROCESS env var (which is set by our faked external command)
+// it will then separate out the arguments required to get go test to insert it from our actual
+// external command arguments.
+//
+// It will then switch on the value of the TEST_CASE env var which each test sets individually so that it
+// knows what to do
+// i.e. return a 0 exit code and a success message to verify our happy path, or a non-zero exit code
+// and a message to stderr to test our error handling
+func TestPDMHelperProcess(t *testing.T) {
+	// Tell go test to use this helper if env var is set
+	if os.Getenv("GO_WANT_PDM_HELPER_PROCESS") != "1" {
+		return
+	}
+
+	// First separate the go test args from what we actually want
+	args := extractCmdArgs(os.Args)
+
+	if os.Getenv("PDM_VENV_IN_PROJECT") != "true" {
+		fmt.Fprintf(os.Stderr, "Error: PDM_VENV_IN_PROJECT not set")
+		os.Exit(1)
+	}
+
+	switch os.Getenv("PDM_TEST_CASE") {
+	case "install_success":
+		expectedArgs := []string{"pdm", "install"}
+		assertCorrectArgs(expectedArgs, args)
+
+	case "install_groups_success":
+		expectedArgs := []string{"pdm", "install", "--group", "lint", "--group", "test"}
+		assertCorrectArgs(expectedArgs, args)
+
+	case "install_error":
+		// Simulate failure by printing to stderr and exit 1
+		fmt.Fprintf(os.Stderr, "something wrong")
+		os.Exit(1)
+	}
+}
+
+func TestInstall(t *testing.T) {
+	tests := []struct {
+		testcase string
+		groups   []string
+		wantErr  bool
+	}{
+		{
+			testcase: "install_success",
+			groups:   nil,
+			wantErr:  false,
+		},
+		{
+			testcase: "install_groups_success",
+			groups:   []string{"lint", "test"},
+			wantErr:  false,
+		},
+		{
+			testcase: "install_error",
+			groups:   nil,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testcase, func(t *testing.T) {
+			setUp(tt.testcase)
+			defer tearDown()
+
+			if err := Install(".", os.Stdout, os.Stderr, tt.groups); (err != nil) != tt.wantErr {
+				t.Errorf("Install() error = %v, wantErr = %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIsPDMProject(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    bool
+	}{
+		{
+			name:    "pdm.backend",
+			content: "[build-system]\nrequires = [\"pdm-backend\"]\nbuild-backend = \"pdm.backend\"\n",
+			want:    true,
+		},
+		{
+			name:    "pdm.pep517.api",
+			content: "[build-system]\nrequires = [\"pdm-pep517\"]\nbuild-backend = \"pdm.pep517.api\"\n",
+			want:    true,
+		},
+		{
+			name:    "something else",
+			content: "[build-system]\nrequires = [\"hatchling\"]\nbuild-backend = \"hatchling.build\"\n",
+			want:    false,
+		},
+		{
+			name:    "not even close",
+			content: `something = "toml"`,
+			want:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			af := afero.Afero{Fs: afero.NewMemMapFs()}
+			if err := af.WriteFile("pyproject.toml", []byte(tt.content), 0o755); err != nil {
+				t.Fatalf("could not create file: %v", err)
+			}
+
+			py, err := pyproject.Read(af)
+			if err != nil {
+				t.Fatalf("could not read pyproject.toml: %v", err)
+			}
+
+			got := IsPDMProject(py)
+
+			if got != tt.want {
+				t.Errorf("got %v, wanted %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDevGroups(t *testing.T) {
+	af := afero.Afero{Fs: afero.NewMemMapFs()}
+
+	content := `[tool.pdm.dev-dependencies]
+test = ["pytest"]
+lint = ["ruff", "mypy"]
+`
+	if err := af.WriteFile("pyproject.toml", []byte(content), 0o755); err != nil {
+		t.Fatalf("could not create file: %v", err)
+	}
+
+	py, err := pyproject.Read(af)
+	if err != nil {
+		t.Fatalf("could not read pyproject.toml: %v", err)
+	}
+
+	got, err := DevGroups(py)
+	if err != nil {
+		t.Fatalf("DevGroups returned an error: %v", err)
+	}
+
+	want := []string{"lint", "test"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, wanted %v", got, want)
+	}
+}
+
+func TestDetector_Detect(t *testing.T) {
+	tests := []struct {
+		files      map[string]string
+		name       string
+		confidence project.Confidence
+	}{
+		{
+			name:       "nothing",
+			files:      map[string]string{},
+			confidence: project.None,
+		},
+		{
+			name:       "pdm backend",
+			files:      map[string]string{"pyproject.toml": "[build-system]\nbuild-backend = \"pdm.backend\"\n"},
+			confidence: project.High,
+		},
+		{
+			name: "other backend with pdm.lock",
+			files: map[string]string{
+				"pyproject.toml": "[build-system]\nbuild-backend = \"hatchling.build\"\n",
+				"pdm.lock":       "",
+			},
+			confidence: project.High,
+		},
+		{
+			name:       "tool.pdm only",
+			files:      map[string]string{"pyproject.toml": "[tool.pdm]\ndistribution = false\n"},
+			confidence: project.Medium,
+		},
+		{
+			name:       "not pdm",
+			files:      map[string]string{"pyproject.toml": "[build-system]\nbuild-backend = \"flit_core.buildapi\"\n"},
+			confidence: project.None,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			af := afero.Afero{Fs: afero.NewMemMapFs()}
+			for file, content := range tt.files {
+				if err := af.WriteFile(file, []byte(content), 0o755); err != nil {
+					t.Fatalf("could not create file: %v", err)
+				}
+			}
+
+			py, err := pyproject.Read(af)
+			if err != nil {
+				t.Fatalf("could not read pyproject.toml: %v", err)
+			}
+
+			got, err := Detector{}.Detect(af, py)
+			if err != nil {
+				t.Fatalf("Detect() returned an error: %v", err)
+			}
+
+			if got.Confidence != tt.confidence {
+				t.Errorf("got confidence %v, wanted %v", got.Confidence, tt.confidence)
+			}
+		})
+	}
+}
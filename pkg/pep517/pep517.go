@@ -0,0 +1,127 @@
+// Package pep517 implements a fallback for projects built with any PEP 517 build
+// backend venv has no dedicated support for e.g. maturin, scikit-build-core or mesonpy
+//
+// The project is installed editable (PEP 660) with pip, which knows how to drive
+// any standards compliant backend
+package pep517
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+
+	"github.com/FollowTheProcess/venv/pkg/project"
+	"github.com/FollowTheProcess/venv/pkg/pyproject"
+	"github.com/FollowTheProcess/venv/pkg/python"
+	"github.com/FollowTheProcess/venv/pkg/setuptools"
+	"github.com/spf13/afero"
+)
+
+// defaultBackend is the backend pip (and every other frontend) uses for a project
+// without a [build-system] table, as specified by PEP 517
+const defaultBackend = "setuptools.build_meta:__legacy__"
+
+// validBackend matches the "module.path" or "module.path:object" form PEP 517
+// requires of a build-backend
+var validBackend = regexp.MustCompile(`^[A-Za-z_]\w*(\.[A-Za-z_]\w*)*(:[A-Za-z_]\w*(\.[A-Za-z_]\w*)*)?$`)
+
+// nonEditable are old backends that predate PEP 660 and so cannot do an editable
+// install, these are installed normally instead
+var nonEditable = map[string]bool{
+	"flit.buildapi":      true,
+	"poetry.masonry.api": true,
+}
+
+// Detector recognises any pyproject.toml that declares a build backend, or a
+// [project] table (which means the PEP 517 default setuptools backend)
+//
+// It only ever has Low confidence, so any detector with dedicated support
+// for the project wins
+type Detector struct {
+	Extras []string // Extras requested by the user, if empty the common development extras are used
+}
+
+// Name implements project.Detector
+func (Detector) Name() string {
+	return "pep517"
+}
+
+// Detect implements project.Detector
+func (d Detector) Detect(fs afero.Afero, py *pyproject.PyProject) (project.Match, error) {
+	if py == nil {
+		return project.Match{}, nil
+	}
+
+	match := project.Match{Confidence: project.Low}
+
+	var backend string
+	switch {
+	case py.BuildSystem != nil:
+		backend = py.Backend()
+		if backend == "" {
+			backend = defaultBackend
+			match.Reasons = append(match.Reasons, "[build-system] has no build-backend, using the PEP 517 default")
+		}
+		if len(py.Requires()) == 0 {
+			match.Plan = unsupported(backend, "[build-system] does not declare it's requires")
+			return match, nil
+		}
+	case py.Project != nil:
+		backend = defaultBackend
+		match.Reasons = append(match.Reasons, "no [build-system], using the PEP 517 default")
+	default:
+		// Just tool configuration, not something we can install
+		return project.Match{}, nil
+	}
+
+	match.Reasons = append(match.Reasons, fmt.Sprintf("build-backend is %s", backend))
+
+	if !validBackend.MatchString(backend) {
+		match.Plan = unsupported(backend, "it is not a valid python object reference")
+		return match, nil
+	}
+
+	available, err := setuptools.Extras(fs, py)
+	if err != nil {
+		return project.Match{}, fmt.Errorf("%w", err)
+	}
+	extras := setuptools.ChooseExtras(available, d.Extras)
+
+	args := setuptools.EditableArgs(extras)
+	if nonEditable[backend] {
+		match.Warnings = append(match.Warnings, fmt.Sprintf("Build backend %q does not support editable installs, the project will be installed normally", backend))
+		args = args[1:]
+	}
+
+	match.Plan = project.Plan{
+		Summary: fmt.Sprintf("Found %q with build backend %q. Creating virtual environment and installing the project", pyproject.File, backend),
+		Steps: []project.Step{
+			{Description: "create virtual environment", Run: python.CreateVenv, Phase: project.Build},
+			{Description: "update seed packages", Run: python.UpdateSeeds},
+			{
+				Description: fmt.Sprintf("pip install %v", args),
+				Run: func(cwd string, stdout, stderr io.Writer) error {
+					return python.Install(cwd, stdout, stderr, args)
+				},
+			},
+		},
+	}
+
+	return match, nil
+}
+
+// unsupported returns a plan that fails straight away, explaining why 'backend'
+// cannot be handled, so the user gets a clear error rather than a half built environment
+func unsupported(backend, why string) project.Plan {
+	return project.Plan{
+		Summary: fmt.Sprintf("Found %q with build backend %q", pyproject.File, backend),
+		Steps: []project.Step{
+			{
+				Description: "check build backend",
+				Run: func(cwd string, stdout, stderr io.Writer) error {
+					return fmt.Errorf("cannot handle build backend %q in %s: %s", backend, pyproject.File, why)
+				},
+			},
+		},
+	}
+}
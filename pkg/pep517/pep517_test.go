@@ -0,0 +1,131 @@
+package pep517
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/FollowTheProcess/venv/pkg/project"
+	"github.com/FollowTheProcess/venv/pkg/pyproject"
+	"github.com/spf13/afero"
+)
+
+func TestDetector_Detect(t *testing.T) {
+	tests := []struct {
+		name       string
+		pyproject  string
+		install    string
+		failure    string
+		warnings   int
+		confidence project.Confidence
+	}{
+		{
+			name:       "no pyproject",
+			pyproject:  "",
+			confidence: project.None,
+		},
+		{
+			name:       "tool config only",
+			pyproject:  "[tool.black]\nline-length = 100\n",
+			confidence: project.None,
+		},
+		{
+			name:       "maturin",
+			pyproject:  "[build-system]\nrequires = [\"maturin>=1.0\"]\nbuild-backend = \"maturin\"\n",
+			install:    "pip install [-e .]",
+			confidence: project.Low,
+		},
+		{
+			name: "scikit-build-core with extras",
+			pyproject: `[build-system]
+requires = ["scikit-build-core"]
+build-backend = "scikit_build_core.build"
+
+[project]
+name = "demo"
+
+[project.optional-dependencies]
+test = ["pytest"]
+`,
+			install:    "pip install [-e .[test]]",
+			confidence: project.Low,
+		},
+		{
+			name:       "setuptools without setup files",
+			pyproject:  "[build-system]\nrequires = [\"setuptools\"]\nbuild-backend = \"setuptools.build_meta\"\n",
+			install:    "pip install [-e .]",
+			confidence: project.Low,
+		},
+		{
+			name:       "no build-system uses default",
+			pyproject:  "[project]\nname = \"demo\"\n",
+			install:    "pip install [-e .]",
+			confidence: project.Low,
+		},
+		{
+			name:       "old poetry can't install editable",
+			pyproject:  "[build-system]\nrequires = [\"poetry>=0.12\"]\nbuild-backend = \"poetry.masonry.api\"\n",
+			install:    "pip install [.]",
+			warnings:   1,
+			confidence: project.Low,
+		},
+		{
+			name:       "invalid backend",
+			pyproject:  "[build-system]\nrequires = [\"thing\"]\nbuild-backend = \"not a backend\"\n",
+			failure:    "not a valid python object reference",
+			confidence: project.Low,
+		},
+		{
+			name:       "missing requires",
+			pyproject:  "[build-system]\nbuild-backend = \"mesonpy\"\n",
+			failure:    "does not declare it's requires",
+			confidence: project.Low,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			af := afero.Afero{Fs: afero.NewMemMapFs()}
+			if tt.pyproject != "" {
+				if err := af.WriteFile(pyproject.File, []byte(tt.pyproject), 0o755); err != nil {
+					t.Fatalf("could not create pyproject.toml: %v", err)
+				}
+			}
+
+			py, err := pyproject.Read(af)
+			if err != nil {
+				t.Fatalf("could not read pyproject.toml: %v", err)
+			}
+
+			got, err := Detector{}.Detect(af, py)
+			if err != nil {
+				t.Fatalf("Detect() returned an error: %v", err)
+			}
+
+			if got.Confidence != tt.confidence {
+				t.Errorf("got confidence %v, wanted %v", got.Confidence, tt.confidence)
+			}
+
+			if len(got.Warnings) != tt.warnings {
+				t.Errorf("got warnings %v, wanted %d", got.Warnings, tt.warnings)
+			}
+
+			if tt.failure != "" {
+				err := got.Plan.Execute(".", io.Discard, io.Discard)
+				if err == nil || !strings.Contains(err.Error(), tt.failure) {
+					t.Errorf("got error %v, wanted it to contain %q", err, tt.failure)
+				}
+				return
+			}
+
+			if tt.install == "" {
+				return
+			}
+
+			steps := got.Plan.Steps
+			if last := steps[len(steps)-1].Description; last != tt.install {
+				t.Errorf("got step %q, wanted %q", last, tt.install)
+			}
+		})
+	}
+}
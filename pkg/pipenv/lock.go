@@ -0,0 +1,120 @@
+package pipenv
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// Lock is the parsed contents of a Pipfile.lock
+type Lock struct {
+	Default map[string]LockedPackage `json:"default"` // The project's runtime dependencies
+	Develop map[string]LockedPackage `json:"develop"` // The project's development dependencies
+}
+
+// LockedPackage is a single entry in a Pipfile.lock section
+type LockedPackage struct {
+	Version  string   `json:"version"`  // The pinned version specifier e.g. "==1.2.3"
+	Markers  string   `json:"markers"`  // Environment markers
+	Hashes   []string `json:"hashes"`   // Hashes of every allowed distribution
+	Extras   []string `json:"extras"`   // Extras of the package to install
+	Git      string   `json:"git"`      // Set if the package comes from a git repo
+	Ref      string   `json:"ref"`      // The git ref to install
+	Path     string   `json:"path"`     // Set if the package is a local path
+	Editable bool     `json:"editable"` // Whether a path or VCS package is editable
+}
+
+// ReadLock reads and parses the Pipfile.lock given by 'path'
+func ReadLock(af afero.Afero, path string) (Lock, error) {
+	data, err := af.ReadFile(path)
+	if err != nil {
+		return Lock{}, fmt.Errorf("could not read %s: %w", path, err)
+	}
+
+	var lock Lock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return Lock{}, fmt.Errorf("could not unmarshall json data: %w", err)
+	}
+
+	return lock, nil
+}
+
+// Requirements converts the lock into pip requirement lines, including the develop
+// section if 'dev' is true
+//
+// Pinned packages carrying hashes are returned in 'hashed' and can be installed with
+// --require-hashes, anything else (git repos, local paths, entries without hashes)
+// is returned in 'other' as pip refuses to mix the two in hash checking mode
+func (l Lock) Requirements(dev bool) (hashed, other []string) {
+	packages := make(map[string]LockedPackage, len(l.Default)+len(l.Develop))
+	for name, pkg := range l.Default {
+		packages[name] = pkg
+	}
+	if dev {
+		for name, pkg := range l.Develop {
+			packages[name] = pkg
+		}
+	}
+
+	names := make([]string, 0, len(packages))
+	for name := range packages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		pkg := packages[name]
+		line := pkg.requirement(name)
+		if pkg.Git == "" && pkg.Path == "" && len(pkg.Hashes) != 0 {
+			hashed = append(hashed, line)
+		} else {
+			other = append(other, line)
+		}
+	}
+
+	return hashed, other
+}
+
+// requirement returns the pip requirement line for the package
+func (p LockedPackage) requirement(name string) string {
+	extras := ""
+	if len(p.Extras) != 0 {
+		extras = fmt.Sprintf("[%s]", strings.Join(p.Extras, ","))
+	}
+
+	switch {
+	case p.Git != "":
+		url := "git+" + p.Git
+		if p.Ref != "" {
+			url = fmt.Sprintf("%s@%s", url, p.Ref)
+		}
+		if p.Editable {
+			return fmt.Sprintf("-e %s#egg=%s", url, name)
+		}
+		return withMarkers(fmt.Sprintf("%s%s @ %s", name, extras, url), p.Markers)
+
+	case p.Path != "":
+		if p.Editable {
+			return fmt.Sprintf("-e %s%s", p.Path, extras)
+		}
+		return withMarkers(p.Path+extras, p.Markers)
+
+	default:
+		line := withMarkers(name+extras+p.Version, p.Markers)
+		for _, hash := range p.Hashes {
+			line = fmt.Sprintf("%s --hash=%s", line, hash)
+		}
+		return line
+	}
+}
+
+// withMarkers appends environment markers to a requirement, if there are any
+func withMarkers(requirement, markers string) string {
+	if markers == "" {
+		return requirement
+	}
+	return fmt.Sprintf("%s ; %s", requirement, markers)
+}
@@ -0,0 +1,113 @@
+package pipenv
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+const lockContent = `{
+    "_meta": {
+        "hash": {"sha256": "abc"},
+        "pipfile-spec": 6,
+        "requires": {"python_version": "3.10"}
+    },
+    "default": {
+        "requests": {
+            "hashes": ["sha256:aaa", "sha256:bbb"],
+            "version": "==2.28.1",
+            "extras": ["socks"]
+        },
+        "colorama": {
+            "hashes": ["sha256:ccc"],
+            "markers": "sys_platform == 'win32'",
+            "version": "==0.4.5"
+        },
+        "demo": {
+            "editable": true,
+            "path": "."
+        }
+    },
+    "develop": {
+        "pytest": {
+            "hashes": ["sha256:ddd"],
+            "version": "==7.1.2"
+        },
+        "tool": {
+            "git": "https://github.com/example/tool.git",
+            "ref": "abc123"
+        }
+    }
+}
+`
+
+func TestReadLock(t *testing.T) {
+	af := afero.Afero{Fs: afero.NewMemMapFs()}
+	if err := af.WriteFile("Pipfile.lock", []byte(lockContent), 0o755); err != nil {
+		t.Fatalf("could not create file: %v", err)
+	}
+
+	lock, err := ReadLock(af, "Pipfile.lock")
+	if err != nil {
+		t.Fatalf("ReadLock returned an error: %v", err)
+	}
+
+	if len(lock.Default) != 3 {
+		t.Errorf("wrong number of default packages: got %d, wanted 3", len(lock.Default))
+	}
+
+	if len(lock.Develop) != 2 {
+		t.Errorf("wrong number of develop packages: got %d, wanted 2", len(lock.Develop))
+	}
+
+	if got := lock.Default["requests"].Version; got != "==2.28.1" {
+		t.Errorf("got requests version %q, wanted %q", got, "==2.28.1")
+	}
+}
+
+func TestLock_Requirements(t *testing.T) {
+	af := afero.Afero{Fs: afero.NewMemMapFs()}
+	if err := af.WriteFile("Pipfile.lock", []byte(lockContent), 0o755); err != nil {
+		t.Fatalf("could not create file: %v", err)
+	}
+
+	lock, err := ReadLock(af, "Pipfile.lock")
+	if err != nil {
+		t.Fatalf("ReadLock returned an error: %v", err)
+	}
+
+	t.Run("with dev", func(t *testing.T) {
+		hashed, other := lock.Requirements(true)
+
+		wantHashed := []string{
+			"colorama==0.4.5 ; sys_platform == 'win32' --hash=sha256:ccc",
+			"pytest==7.1.2 --hash=sha256:ddd",
+			"requests[socks]==2.28.1 --hash=sha256:aaa --hash=sha256:bbb",
+		}
+		wantOther := []string{
+			"-e .",
+			"tool @ git+https://github.com/example/tool.git@abc123",
+		}
+
+		if !reflect.DeepEqual(hashed, wantHashed) {
+			t.Errorf("got hashed %#v, wanted %#v", hashed, wantHashed)
+		}
+
+		if !reflect.DeepEqual(other, wantOther) {
+			t.Errorf("got other %#v, wanted %#v", other, wantOther)
+		}
+	})
+
+	t.Run("without dev", func(t *testing.T) {
+		hashed, other := lock.Requirements(false)
+
+		if len(hashed) != 2 {
+			t.Errorf("wrong number of hashed requirements: got %d, wanted 2", len(hashed))
+		}
+
+		if len(other) != 1 {
+			t.Errorf("wrong number of other requirements: got %d, wanted 1", len(other))
+		}
+	})
+}
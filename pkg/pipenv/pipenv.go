@@ -0,0 +1,202 @@
+// Package pipenv implements support for projects managed with pipenv, either by
+// wrapping pipenv commands or, if pipenv is not installed, installing straight
+// from the Pipfile.lock with pip
+package pipenv
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/FollowTheProcess/venv/pkg/project"
+	"github.com/FollowTheProcess/venv/pkg/pyproject"
+	"github.com/FollowTheProcess/venv/pkg/python"
+	"github.com/spf13/afero"
+)
+
+var (
+	pipenvCommand = exec.Command
+	lookPath      = exec.LookPath
+)
+
+const (
+	pipfile     = "Pipfile"
+	pipfileLock = "Pipfile.lock"
+)
+
+// inProjectEnv tells pipenv to create it's virtual environment as .venv in the project
+const inProjectEnv = "PIPENV_VENV_IN_PROJECT=1"
+
+// newPipenvCommand returns an exec.Cmd configured with the parameters passed in
+func newPipenvCommand(cwd string, stdout, stderr io.Writer, args []string) *exec.Cmd {
+	cmd := pipenvCommand("pipenv", args...)
+	cmd.Dir = cwd
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	// A nil Env means inherit ours, so make that explicit before adding to it
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env, inProjectEnv)
+
+	return cmd
+}
+
+// Install calls pipenv install --dev, resolving the Pipfile and creating a lock
+func Install(cwd string, stdout, stderr io.Writer) error {
+	cmd := newPipenvCommand(cwd, stdout, stderr, []string{"install", "--dev"})
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("could not create pipenv environment: %w", err)
+	}
+
+	return nil
+}
+
+// Sync calls pipenv sync --dev, installing exactly what is in the Pipfile.lock
+func Sync(cwd string, stdout, stderr io.Writer) error {
+	cmd := newPipenvCommand(cwd, stdout, stderr, []string{"sync", "--dev"})
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("could not sync pipenv environment: %w", err)
+	}
+
+	return nil
+}
+
+// InstallLocked uses pip to install every package in 'lock' into the virtual
+// environment in cwd, checking hashes wherever the lock provides them
+func InstallLocked(cwd string, stdout, stderr io.Writer, lock Lock) error {
+	hashed, other := lock.Requirements(true)
+
+	if err := installLines(cwd, stdout, stderr, hashed, true); err != nil {
+		return fmt.Errorf("could not install locked packages: %w", err)
+	}
+
+	if err := installLines(cwd, stdout, stderr, other, false); err != nil {
+		return fmt.Errorf("could not install unhashed packages: %w", err)
+	}
+
+	return nil
+}
+
+// installLines writes the requirement 'lines' to a temporary requirements file
+// and pip installs it
+func installLines(cwd string, stdout, stderr io.Writer, lines []string, requireHashes bool) error {
+	if len(lines) == 0 {
+		return nil
+	}
+
+	file, err := os.CreateTemp("", "venv-pipfile-*.txt")
+	if err != nil {
+		return fmt.Errorf("could not create requirements file: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.WriteString(strings.Join(lines, "\n") + "\n"); err != nil {
+		file.Close()
+		return fmt.Errorf("could not write requirements file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("could not write requirements file: %w", err)
+	}
+
+	args := []string{"-r", file.Name()}
+	if requireHashes {
+		args = append([]string{"--require-hashes"}, args...)
+	}
+
+	if err := python.Install(cwd, stdout, stderr, args); err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	return nil
+}
+
+// Detector recognises projects with a Pipfile or Pipfile.lock
+type Detector struct{}
+
+// Name implements project.Detector
+func (Detector) Name() string {
+	return "pipenv"
+}
+
+// Detect implements project.Detector
+func (Detector) Detect(fs afero.Afero, _ *pyproject.PyProject) (project.Match, error) {
+	hasPipfile, err := fs.Exists(pipfile)
+	if err != nil {
+		return project.Match{}, fmt.Errorf("could not check for %s: %w", pipfile, err)
+	}
+
+	hasLock, err := fs.Exists(pipfileLock)
+	if err != nil {
+		return project.Match{}, fmt.Errorf("could not check for %s: %w", pipfileLock, err)
+	}
+
+	if !hasPipfile && !hasLock {
+		return project.Match{}, nil
+	}
+
+	var reasons []string
+	if hasPipfile {
+		reasons = append(reasons, fmt.Sprintf("found %s", pipfile))
+	}
+	if hasLock {
+		reasons = append(reasons, fmt.Sprintf("found %s", pipfileLock))
+	}
+
+	match := project.Match{Confidence: project.High, Reasons: reasons}
+
+	_, err = lookPath("pipenv")
+	hasPipenv := err == nil
+
+	switch {
+	case hasPipenv && hasPipfile && hasLock:
+		match.Plan = project.Plan{
+			Summary: fmt.Sprintf("Found %q. Installing locked dependencies with pipenv", pipfileLock),
+			Steps:   []project.Step{{Description: "pipenv sync --dev", Run: Sync}},
+		}
+
+	case hasPipenv && hasPipfile:
+		match.Plan = project.Plan{
+			Summary: fmt.Sprintf("Found %q. Installing dependencies with pipenv", pipfile),
+			Steps:   []project.Step{{Description: "pipenv install --dev", Run: Install}},
+		}
+
+	case hasLock:
+		reason := "pipenv is not installed, installing from the lock with pip"
+		if hasPipenv {
+			reason = fmt.Sprintf("there is no %s for pipenv to sync against, installing from the lock with pip", pipfile)
+		}
+		match.Reasons = append(match.Reasons, reason)
+		lock, err := ReadLock(fs, pipfileLock)
+		if err != nil {
+			return project.Match{}, fmt.Errorf("%w", err)
+		}
+		match.Plan = project.Plan{
+			Summary: fmt.Sprintf("Found %q. Creating virtual environment and installing locked dependencies", pipfileLock),
+			Steps: []project.Step{
+				{Description: "create virtual environment", Run: python.CreateVenv, Phase: project.Build},
+				{Description: "update seed packages", Run: python.UpdateSeeds},
+				{
+					Description: fmt.Sprintf("pip install packages from %s", pipfileLock),
+					Run: func(cwd string, stdout, stderr io.Writer) error {
+						return InstallLocked(cwd, stdout, stderr, lock)
+					},
+				},
+			},
+		}
+
+	default:
+		// No lock to fall back on so pipenv itself is needed
+		match.Reasons = append(match.Reasons, fmt.Sprintf("pipenv is not installed and there is no %s", pipfileLock))
+		match.Plan = project.Plan{
+			Summary: fmt.Sprintf("Found %q. Installing dependencies with pipenv", pipfile),
+			Steps:   []project.Step{{Description: "pipenv install --dev", Run: Install}},
+			Tools:   []string{"pipenv"},
+		}
+	}
+
+	return match, nil
+}
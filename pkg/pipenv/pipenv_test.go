@@ -0,0 +1,288 @@
+package pipenv
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"reflect"
+	"testing"
+
+	"github.com/FollowTheProcess/venv/pkg/project"
+	"github.com/spf13/afero"
+)
+
+// testCase is used as an env var to pass around so our test helper
+// knows what condition to test for
+var testCase string
+
+// extractCmdArgs is a helper for TestHelperProcess which teases out the desired
+// external command arguments from the special ones required to make go test use the
+// helper process
+func extractCmdArgs(args []string) []string {
+	for len(args) > 0 {
+		if args[0] == "--" {
+			args = args[1:]
+			break
+		}
+		args = args[1:]
+	}
+	return args
+}
+
+// assertCorrectArgs compares external command arguments to verify correctness
+// designed to be used inside the external command TestHelperProcess
+func assertCorrectArgs(expected, args []string) {
+	if !reflect.DeepEqual(args, expected) {
+		fmt.Fprintf(os.Stderr, "Error: expected cmd %#v, got %#v", expected, args)
+		os.Exit(1)
+	}
+}
+
+// fakeExecCommand is a helper that creates a fake external command
+// It does some clever magic and uses the way go test runs to insert itself
+// during a test in place of an actual command
+// it's used in the std lib to test exec
+// see: https://npf.io/2015/06/testing-exec-command/
+func fakeExecCommand(command string, args ...string) *exec.Cmd {
+	cs := []string{"-test.run=TestPipenvHelperProcess", "--", command}
+	cs = append(cs, args...)
+
+	cmd := exec.Command(os.Args[0], cs...)
+	// By passing env variables like this, we can control the behaviour of our
+	// mocked command
+	// For example, have it return a non-zero exit code etc.
+	tc := "PIPENV_TEST_CASE=" + testCase
+	cmd.Env = []string{"GO_WANT_PIPENV_HELPER_PROCESS=1", tc}
+	return cmd
+}
+
+// fakeLookPath returns a lookPath that either finds every executable or none
+func fakeLookPath(found bool) func(string) (string, error) {
+	return func(file string) (string, error) {
+		if found {
+			return "/usr/bin/" + file, nil
+		}
+		return "", errors.New("not found")
+	}
+}
+
+func setUp(testcase string) {
+	pipenvCommand = fakeExecCommand
+	testCase = testcase
+}
+
+func tearDown() {
+	pipenvCommand = exec.Command
+	lookPath = exec.LookPath
+}
+
+// This is the main helper process for external command tests. It first checks whether or not go test wants to use it
+// by looking for the GO_WANT_HELPER_PROCESS env var (which is set by our faked external command)
+// it will then separate out the arguments required to get go test to insert it from our actual
+// external command arguments.
+//
+// It will then switch on the value of the TEST_CASE env var which each test sets individually so that it
+// knows what to do
+// i.e. return a 0 exit code and a success message to verify our happy path, or a non-zero exit code
+// and a message to stderr to test our error handling
+func TestPipenvHelperProcess(t *testing.T) {
+	// Tell go test to use this helper if env var is set
+	if os.Getenv("GO_WANT_PIPENV_HELPER_PROCESS") != "1" {
+		return
+	}
+
+	// First separate the go test args from what we actually want
+	args := extractCmdArgs(os.Args)
+
+	if os.Getenv("PIPENV_VENV_IN_PROJECT") != "1" {
+		fmt.Fprintf(os.Stderr, "Error: PIPENV_VENV_IN_PROJECT not set")
+		os.Exit(1)
+	}
+
+	switch os.Getenv("PIPENV_TEST_CASE") {
+	case "install_success":
+		expectedArgs := []string{"pipenv", "install", "--dev"}
+		assertCorrectArgs(expectedArgs, args)
+
+	case "sync_success":
+		expectedArgs := []string{"pipenv", "sync", "--dev"}
+		assertCorrectArgs(expectedArgs, args)
+
+	case "install_error", "sync_error":
+		// Simulate failure by printing to stderr and exit 1
+		fmt.Fprintf(os.Stderr, "something wrong")
+		os.Exit(1)
+	}
+}
+
+func TestInstall(t *testing.T) {
+	tests := []struct {
+		testcase string
+		wantErr  bool
+	}{
+		{
+			testcase: "install_success",
+			wantErr:  false,
+		},
+		{
+			testcase: "install_error",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testcase, func(t *testing.T) {
+			setUp(tt.testcase)
+			defer tearDown()
+
+			if err := Install(".", os.Stdout, os.Stderr); (err != nil) != tt.wantErr {
+				t.Errorf("Install() error = %v, wantErr = %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSync(t *testing.T) {
+	tests := []struct {
+		testcase string
+		wantErr  bool
+	}{
+		{
+			testcase: "sync_success",
+			wantErr:  false,
+		},
+		{
+			testcase: "sync_error",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testcase, func(t *testing.T) {
+			setUp(tt.testcase)
+			defer tearDown()
+
+			if err := Sync(".", os.Stdout, os.Stderr); (err != nil) != tt.wantErr {
+				t.Errorf("Sync() error = %v, wantErr = %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDetector_Detect(t *testing.T) {
+	tests := []struct {
+		files      []string
+		name       string
+		step       string
+		reason     string // A reason the match must give, if set
+		confidence project.Confidence
+		hasPipenv  bool
+	}{
+		{
+			name:       "nothing",
+			files:      []string{},
+			hasPipenv:  true,
+			confidence: project.None,
+		},
+		{
+			name:       "pipenv with lock",
+			files:      []string{"Pipfile", "Pipfile.lock"},
+			hasPipenv:  true,
+			step:       "pipenv sync --dev",
+			confidence: project.High,
+		},
+		{
+			name:       "pipenv without lock",
+			files:      []string{"Pipfile"},
+			hasPipenv:  true,
+			step:       "pipenv install --dev",
+			confidence: project.High,
+		},
+		{
+			name:       "no pipenv with lock",
+			files:      []string{"Pipfile", "Pipfile.lock"},
+			hasPipenv:  false,
+			step:       "pip install packages from Pipfile.lock",
+			reason:     "pipenv is not installed, installing from the lock with pip",
+			confidence: project.High,
+		},
+		{
+			name:       "pipenv with only a lock",
+			files:      []string{"Pipfile.lock"},
+			hasPipenv:  true,
+			step:       "pip install packages from Pipfile.lock",
+			reason:     "there is no Pipfile for pipenv to sync against, installing from the lock with pip",
+			confidence: project.High,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lookPath = fakeLookPath(tt.hasPipenv)
+			defer tearDown()
+
+			af := afero.Afero{Fs: afero.NewMemMapFs()}
+			for _, file := range tt.files {
+				content := ""
+				if file == "Pipfile.lock" {
+					content = lockContent
+				}
+				if err := af.WriteFile(file, []byte(content), 0o755); err != nil {
+					t.Fatalf("could not create file: %v", err)
+				}
+			}
+
+			got, err := Detector{}.Detect(af, nil)
+			if err != nil {
+				t.Fatalf("Detect() returned an error: %v", err)
+			}
+
+			if got.Confidence != tt.confidence {
+				t.Errorf("got confidence %v, wanted %v", got.Confidence, tt.confidence)
+			}
+
+			if tt.reason != "" {
+				found := false
+				for _, reason := range got.Reasons {
+					found = found || reason == tt.reason
+				}
+				if !found {
+					t.Errorf("reason %q missing from %v", tt.reason, got.Reasons)
+				}
+			}
+
+			if tt.step == "" {
+				return
+			}
+
+			steps := got.Plan.Steps
+			if last := steps[len(steps)-1].Description; last != tt.step {
+				t.Errorf("got step %q, wanted %q", last, tt.step)
+			}
+		})
+	}
+
+	t.Run("no pipenv and no lock needs pipenv", func(t *testing.T) {
+		lookPath = fakeLookPath(false)
+		defer tearDown()
+
+		af := afero.Afero{Fs: afero.NewMemMapFs()}
+		if err := af.WriteFile("Pipfile", []byte(""), 0o755); err != nil {
+			t.Fatalf("could not create file: %v", err)
+		}
+
+		got, err := Detector{}.Detect(af, nil)
+		if err != nil {
+			t.Fatalf("Detect() returned an error: %v", err)
+		}
+
+		if want := []string{"pipenv"}; !reflect.DeepEqual(got.Plan.Tools, want) {
+			t.Errorf("got tools %v, wanted %v", got.Plan.Tools, want)
+		}
+
+		if step := got.Plan.Steps[0].Description; step != "pipenv install --dev" {
+			t.Errorf("got step %q, wanted %q", step, "pipenv install --dev")
+		}
+	})
+}
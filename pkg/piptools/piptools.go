@@ -0,0 +1,237 @@
+// Package piptools implements support for projects using the pip-tools workflow
+// where top level dependencies live in requirements.in files which are compiled
+// to pinned requirements.txt files with pip-compile and installed with pip-sync
+package piptools
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/FollowTheProcess/venv/pkg/project"
+	"github.com/FollowTheProcess/venv/pkg/pyproject"
+	"github.com/FollowTheProcess/venv/pkg/python"
+	"github.com/FollowTheProcess/venv/pkg/requirements"
+	"github.com/spf13/afero"
+)
+
+// module is the python module pip-tools is run as
+const module = "piptools"
+
+// patterns are the glob patterns matching the common requirements.in layouts
+var patterns = []string{
+	"requirements*.in",
+	"*-requirements.in",
+	"*_requirements.in",
+	"requirements/*.in",
+}
+
+// Source is a requirements.in file along with the file it compiles to
+type Source struct {
+	In    string // The requirements.in file
+	Txt   string // The compiled requirements.txt file
+	Stale bool   // Whether Txt is missing or older than In, and so must be compiled
+}
+
+// Discover finds every requirements.in file in the project, ranked in the same
+// way as requirements files, and works out which need compiling
+//
+// Any file pulled in by another with -r or -c (e.g. the layered pip-tools setup where
+// requirements-dev.in has "-c requirements.txt") comes before it, so it's compiled first
+func Discover(fs afero.Afero) ([]Source, error) {
+	seen := make(map[string]bool)
+	var files []string
+	for _, pattern := range patterns {
+		matches, err := afero.Glob(fs, pattern)
+		if err != nil {
+			return nil, fmt.Errorf("bad pattern %q: %w", pattern, err)
+		}
+		for _, match := range matches {
+			match = filepath.ToSlash(match)
+			if !seen[match] {
+				seen[match] = true
+				files = append(files, match)
+			}
+		}
+	}
+
+	sort.SliceStable(files, func(i, j int) bool {
+		ri, rj := requirements.Rank(files[i]), requirements.Rank(files[j])
+		if ri != rj {
+			return ri < rj
+		}
+		return files[i] < files[j]
+	})
+
+	sources := make([]Source, 0, len(files))
+	for _, in := range files {
+		source, err := newSource(fs, in)
+		if err != nil {
+			return nil, fmt.Errorf("%w", err)
+		}
+		sources = append(sources, source)
+	}
+
+	return order(fs, sources)
+}
+
+// order sorts 'sources' so each comes after the sources whose .in or compiled .txt
+// it includes, otherwise keeping their ranked order
+func order(fs afero.Afero, sources []Source) ([]Source, error) {
+	index := make(map[string]int, 2*len(sources))
+	for i, source := range sources {
+		index[source.In] = i
+		index[source.Txt] = i
+	}
+
+	deps := make([][]int, len(sources))
+	for i, source := range sources {
+		refs, err := requirements.Referenced(fs, source.In)
+		if err != nil {
+			return nil, fmt.Errorf("%w", err)
+		}
+		for _, ref := range refs {
+			if j, ok := index[ref]; ok && j != i {
+				deps[i] = append(deps[i], j)
+			}
+		}
+	}
+
+	// Marking a source as visited before its dependencies means a cycle of
+	// includes just falls back to the ranked order rather than looping forever
+	visited := make([]bool, len(sources))
+	ordered := make([]Source, 0, len(sources))
+	var visit func(i int)
+	visit = func(i int) {
+		if visited[i] {
+			return
+		}
+		visited[i] = true
+		for _, j := range deps[i] {
+			visit(j)
+		}
+		ordered = append(ordered, sources[i])
+	}
+	for i := range sources {
+		visit(i)
+	}
+
+	return ordered, nil
+}
+
+// newSource returns the Source for the requirements.in file 'in'
+func newSource(fs afero.Afero, in string) (Source, error) {
+	txt := strings.TrimSuffix(in, ".in") + ".txt"
+
+	inInfo, err := fs.Stat(in)
+	if err != nil {
+		return Source{}, fmt.Errorf("could not stat %s: %w", in, err)
+	}
+
+	exists, err := fs.Exists(txt)
+	if err != nil {
+		return Source{}, fmt.Errorf("could not check for %s: %w", txt, err)
+	}
+	if !exists {
+		return Source{In: in, Txt: txt, Stale: true}, nil
+	}
+
+	txtInfo, err := fs.Stat(txt)
+	if err != nil {
+		return Source{}, fmt.Errorf("could not stat %s: %w", txt, err)
+	}
+
+	return Source{In: in, Txt: txt, Stale: txtInfo.ModTime().Before(inInfo.ModTime())}, nil
+}
+
+// InstallTools installs pip-tools into the virtual environment in cwd
+func InstallTools(cwd string, stdout, stderr io.Writer) error {
+	if err := python.Install(cwd, stdout, stderr, []string{"pip-tools"}); err != nil {
+		return fmt.Errorf("could not install pip-tools: %w", err)
+	}
+
+	return nil
+}
+
+// Compile calls pip-compile on the requirements.in file 'in', writing the result to 'out'
+func Compile(cwd string, stdout, stderr io.Writer, in, out string) error {
+	if err := python.RunModule(cwd, stdout, stderr, module, []string{"compile", "--output-file", out, in}); err != nil {
+		return fmt.Errorf("could not compile %s: %w", in, err)
+	}
+
+	return nil
+}
+
+// Sync calls pip-sync so the virtual environment matches 'files' exactly
+func Sync(cwd string, stdout, stderr io.Writer, files []string) error {
+	args := append([]string{"sync"}, files...)
+	if err := python.RunModule(cwd, stdout, stderr, module, args); err != nil {
+		return fmt.Errorf("could not sync environment: %w", err)
+	}
+
+	return nil
+}
+
+// Detector recognises projects using pip-tools from their requirements.in files
+type Detector struct{}
+
+// Name implements project.Detector
+func (Detector) Name() string {
+	return "pip-tools"
+}
+
+// Detect implements project.Detector
+func (Detector) Detect(fs afero.Afero, _ *pyproject.PyProject) (project.Match, error) {
+	sources, err := Discover(fs)
+	if err != nil {
+		return project.Match{}, fmt.Errorf("%w", err)
+	}
+	if len(sources) == 0 {
+		return project.Match{}, nil
+	}
+
+	steps := []project.Step{
+		{Description: "create virtual environment", Run: python.CreateVenv, Phase: project.Build},
+		{Description: "update seed packages", Run: python.UpdateSeeds},
+		{Description: "install pip-tools", Run: InstallTools},
+	}
+
+	var reasons, compiled, quoted []string
+	for _, source := range sources {
+		source := source
+		reasons = append(reasons, fmt.Sprintf("found %s", source.In))
+		quoted = append(quoted, fmt.Sprintf("%q", source.In))
+		compiled = append(compiled, source.Txt)
+
+		if !source.Stale {
+			reasons = append(reasons, fmt.Sprintf("%s is up to date", source.Txt))
+			continue
+		}
+
+		reasons = append(reasons, fmt.Sprintf("%s is missing or older than %s", source.Txt, source.In))
+		steps = append(steps, project.Step{
+			Description: fmt.Sprintf("pip-compile %s", source.In),
+			Run: func(cwd string, stdout, stderr io.Writer) error {
+				return Compile(cwd, stdout, stderr, source.In, source.Txt)
+			},
+		})
+	}
+
+	steps = append(steps, project.Step{
+		Description: fmt.Sprintf("pip-sync %s", strings.Join(compiled, " ")),
+		Run: func(cwd string, stdout, stderr io.Writer) error {
+			return Sync(cwd, stdout, stderr, compiled)
+		},
+	})
+
+	return project.Match{
+		Confidence: project.High,
+		Reasons:    reasons,
+		Plan: project.Plan{
+			Summary: fmt.Sprintf("Found %s. Creating virtual environment and syncing with pip-tools", strings.Join(quoted, ", ")),
+			Steps:   steps,
+		},
+	}, nil
+}
@@ -0,0 +1,161 @@
+package piptools
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/FollowTheProcess/venv/pkg/project"
+	"github.com/spf13/afero"
+)
+
+// writeFiles creates each of 'files' in the filesystem, with the modification
+// time given by it's value
+func writeFiles(t *testing.T, af afero.Afero, files map[string]time.Time) {
+	t.Helper()
+	for file, modTime := range files {
+		if err := af.WriteFile(file, []byte(""), 0o755); err != nil {
+			t.Fatalf("could not create file: %v", err)
+		}
+		if err := af.Chtimes(file, modTime, modTime); err != nil {
+			t.Fatalf("could not set file times: %v", err)
+		}
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	older := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Hour)
+
+	af := afero.Afero{Fs: afero.NewMemMapFs()}
+	writeFiles(t, af, map[string]time.Time{
+		"requirements.in":      older,
+		"requirements.txt":     newer, // Up to date
+		"requirements-dev.in":  newer,
+		"requirements-dev.txt": older, // Stale
+		"requirements/docs.in": older, // Never compiled
+	})
+
+	got, err := Discover(af)
+	if err != nil {
+		t.Fatalf("Discover returned an error: %v", err)
+	}
+
+	want := []Source{
+		{In: "requirements-dev.in", Txt: "requirements-dev.txt", Stale: true},
+		{In: "requirements.in", Txt: "requirements.txt", Stale: false},
+		{In: "requirements/docs.in", Txt: "requirements/docs.txt", Stale: true},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, wanted %#v", got, want)
+	}
+}
+
+func TestDetector_Detect(t *testing.T) {
+	older := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Hour)
+
+	tests := []struct {
+		name       string
+		files      map[string]time.Time
+		steps      []string
+		confidence project.Confidence
+	}{
+		{
+			name:       "nothing",
+			files:      map[string]time.Time{"requirements.txt": older},
+			confidence: project.None,
+		},
+		{
+			name:  "never compiled",
+			files: map[string]time.Time{"requirements.in": older},
+			steps: []string{
+				"create virtual environment",
+				"update seed packages",
+				"install pip-tools",
+				"pip-compile requirements.in",
+				"pip-sync requirements.txt",
+			},
+			confidence: project.High,
+		},
+		{
+			name: "up to date",
+			files: map[string]time.Time{
+				"requirements.in":      older,
+				"requirements.txt":     newer,
+				"requirements-dev.in":  older,
+				"requirements-dev.txt": newer,
+			},
+			steps: []string{
+				"create virtual environment",
+				"update seed packages",
+				"install pip-tools",
+				"pip-sync requirements-dev.txt requirements.txt",
+			},
+			confidence: project.High,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			af := afero.Afero{Fs: afero.NewMemMapFs()}
+			writeFiles(t, af, tt.files)
+
+			got, err := Detector{}.Detect(af, nil)
+			if err != nil {
+				t.Fatalf("Detect() returned an error: %v", err)
+			}
+
+			if got.Confidence != tt.confidence {
+				t.Errorf("got confidence %v, wanted %v", got.Confidence, tt.confidence)
+			}
+
+			var steps []string
+			for _, step := range got.Plan.Steps {
+				steps = append(steps, step.Description)
+			}
+
+			if !reflect.DeepEqual(steps, tt.steps) {
+				t.Errorf("got steps %v, wanted %v", steps, tt.steps)
+			}
+		})
+	}
+}
+
+func TestDetector_Layered(t *testing.T) {
+	// pip-tools' layered requirements, on a fresh checkout nothing is compiled yet
+	af := afero.Afero{Fs: afero.NewMemMapFs()}
+	files := map[string]string{
+		"requirements.in":     "requests\n",
+		"requirements-dev.in": "-c requirements.txt\npytest\n",
+	}
+	for file, content := range files {
+		if err := af.WriteFile(file, []byte(content), 0o755); err != nil {
+			t.Fatalf("could not create file: %v", err)
+		}
+	}
+
+	got, err := Detector{}.Detect(af, nil)
+	if err != nil {
+		t.Fatalf("Detect() returned an error: %v", err)
+	}
+
+	var steps []string
+	for _, step := range got.Plan.Steps {
+		steps = append(steps, step.Description)
+	}
+
+	// requirements.txt must exist before requirements-dev.in can be compiled against it
+	want := []string{
+		"create virtual environment",
+		"update seed packages",
+		"install pip-tools",
+		"pip-compile requirements.in",
+		"pip-compile requirements-dev.in",
+		"pip-sync requirements.txt requirements-dev.txt",
+	}
+	if !reflect.DeepEqual(steps, want) {
+		t.Errorf("got steps %v, wanted %v", steps, want)
+	}
+}
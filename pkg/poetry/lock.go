@@ -0,0 +1,312 @@
+package poetry
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/FollowTheProcess/venv/pkg/pyproject"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/spf13/afero"
+)
+
+// lockFile is poetry's lock file
+const lockFile = "poetry.lock"
+
+// legacyKeys are the keys of [tool.poetry] always included in poetry's content hash
+// (as null if missing) unless the project uses a [project] table, relevantKeys
+// are every key of [tool.poetry] included if present
+var (
+	legacyKeys   = []string{"dependencies", "source", "extras", "dev-dependencies"}
+	relevantKeys = append(append([]string{}, legacyKeys...), "group")
+)
+
+// lock is the part of poetry.lock venv cares about
+type lock struct {
+	Metadata struct {
+		ContentHash string `toml:"content-hash"`
+	} `toml:"metadata"`
+}
+
+// Lock calls poetry lock, bringing the lock file back in line with pyproject.toml
+// without upgrading anything
+//
+// That's what poetry lock does by default from poetry 2, which removed the --no-update
+// flag older versions need to do the same
+func Lock(cwd string, stdout, stderr io.Writer) error {
+	major, err := MajorVersion(cwd)
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	args := []string{"lock"}
+	if major < 2 {
+		args = append(args, "--no-update")
+	}
+
+	cmd := newPoetryCommand(cwd, stdout, stderr, args)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("could not update %s: %w", lockFile, err)
+	}
+
+	return nil
+}
+
+// MajorVersion returns the major version of the installed poetry, from the output
+// of poetry --version e.g. "Poetry (version 2.0.1)" or "Poetry version 1.1.13"
+func MajorVersion(cwd string) (int, error) {
+	stdout := &bytes.Buffer{}
+	cmd := newPoetryCommand(cwd, stdout, io.Discard, []string{"--version"})
+	if err := cmd.Run(); err != nil {
+		return 0, fmt.Errorf("could not get poetry version: %w", err)
+	}
+
+	fields := strings.Fields(stdout.String())
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("could not parse poetry version from %q", stdout.String())
+	}
+	version := strings.TrimSuffix(fields[len(fields)-1], ")")
+	major, _, _ := strings.Cut(version, ".")
+
+	n, err := strconv.Atoi(major)
+	if err != nil {
+		return 0, fmt.Errorf("could not parse poetry version from %q: %w", stdout.String(), err)
+	}
+
+	return n, nil
+}
+
+// Stale reports whether poetry.lock is out of date with the project's pyproject.toml,
+// i.e. the content hash poetry recorded when locking no longer matches. A missing lock
+// file, or one without a content hash, is not considered stale
+func Stale(fs afero.Afero, py *pyproject.PyProject) (bool, error) {
+	exists, err := fs.Exists(lockFile)
+	if err != nil {
+		return false, fmt.Errorf("could not check for %s: %w", lockFile, err)
+	}
+	if !exists {
+		return false, nil
+	}
+
+	data, err := fs.ReadFile(lockFile)
+	if err != nil {
+		return false, fmt.Errorf("could not read %s: %w", lockFile, err)
+	}
+
+	var l lock
+	if err := toml.Unmarshal(data, &l); err != nil {
+		return false, fmt.Errorf("could not parse %s: %w", lockFile, err)
+	}
+	if l.Metadata.ContentHash == "" {
+		return false, nil
+	}
+
+	hash, err := ContentHash(py)
+	if err != nil {
+		return false, fmt.Errorf("%w", err)
+	}
+
+	return hash != l.Metadata.ContentHash, nil
+}
+
+// ContentHash returns the hash poetry records in poetry.lock for the project's
+// pyproject.toml, the sha256 of the json encoded dependency related parts of it
+//
+// This must match poetry's own implementation exactly, down to the json
+// formatting python uses, or every lock file would look stale
+func ContentHash(py *pyproject.PyProject) (string, error) {
+	project := make(map[string]interface{})
+	if py != nil && py.Project != nil {
+		if py.Project.RequiresPython != "" {
+			project["requires-python"] = py.Project.RequiresPython
+		}
+		if py.Project.Dependencies != nil {
+			project["dependencies"] = toInterfaces(py.Project.Dependencies)
+		}
+		if py.Project.OptionalDependencies != nil {
+			extras := make(map[string]interface{}, len(py.Project.OptionalDependencies))
+			for extra, deps := range py.Project.OptionalDependencies {
+				extras[extra] = toInterfaces(deps)
+			}
+			project["optional-dependencies"] = extras
+		}
+	}
+
+	var config map[string]interface{}
+	if py != nil {
+		config, _ = py.Tool["poetry"].(map[string]interface{})
+	}
+
+	relevant := make(map[string]interface{})
+	for _, key := range relevantKeys {
+		data, ok := config[key]
+		if !ok && (!isLegacy(key) || len(project) != 0) {
+			continue
+		}
+		relevant[key] = data
+	}
+
+	// Projects without a [project] table keep [tool.poetry]'s content at the top level
+	content := relevant
+	if len(project) != 0 {
+		content = map[string]interface{}{
+			"project": project,
+			"tool":    map[string]interface{}{"poetry": relevant},
+		}
+	}
+
+	var b strings.Builder
+	if err := encodePython(&b, content); err != nil {
+		return "", fmt.Errorf("could not compute content hash: %w", err)
+	}
+
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// isLegacy reports whether 'key' is one of legacyKeys
+func isLegacy(key string) bool {
+	for _, legacy := range legacyKeys {
+		if key == legacy {
+			return true
+		}
+	}
+
+	return false
+}
+
+// toInterfaces converts a []string to the []interface{} the encoder expects
+func toInterfaces(items []string) []interface{} {
+	converted := make([]interface{}, 0, len(items))
+	for _, item := range items {
+		converted = append(converted, item)
+	}
+
+	return converted
+}
+
+// encodePython writes 'v' to 'b' exactly as python's json.dumps(v, sort_keys=True)
+// would: ", " and ": " separators, sorted keys and non ascii characters escaped
+func encodePython(b *strings.Builder, v interface{}) error {
+	switch value := v.(type) {
+	case nil:
+		b.WriteString("null")
+	case bool:
+		b.WriteString(strconv.FormatBool(value))
+	case string:
+		encodePythonString(b, value)
+	case int64:
+		b.WriteString(strconv.FormatInt(value, 10))
+	case float64:
+		b.WriteString(pythonFloat(value))
+	case time.Time, toml.LocalDate, toml.LocalTime, toml.LocalDateTime:
+		// Not json serialisable in python either, poetry would have failed too
+		return fmt.Errorf("cannot encode %T", value)
+	case []interface{}:
+		b.WriteString("[")
+		for i, item := range value {
+			if i != 0 {
+				b.WriteString(", ")
+			}
+			if err := encodePython(b, item); err != nil {
+				return err
+			}
+		}
+		b.WriteString("]")
+	case map[string]interface{}:
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		b.WriteString("{")
+		for i, key := range keys {
+			if i != 0 {
+				b.WriteString(", ")
+			}
+			encodePythonString(b, key)
+			b.WriteString(": ")
+			if err := encodePython(b, value[key]); err != nil {
+				return err
+			}
+		}
+		b.WriteString("}")
+	default:
+		return fmt.Errorf("cannot encode %T", value)
+	}
+
+	return nil
+}
+
+// encodePythonString writes 's' as a json string the way python does with
+// ensure_ascii, anything outside ascii becomes a (lowercase) \u escape
+func encodePythonString(b *strings.Builder, s string) {
+	b.WriteString(`"`)
+	for _, r := range s {
+		switch {
+		case r == '"':
+			b.WriteString(`\"`)
+		case r == '\\':
+			b.WriteString(`\\`)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r == '\b':
+			b.WriteString(`\b`)
+		case r == '\f':
+			b.WriteString(`\f`)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(b, `\u%04x`, r)
+		case r < 0x7f:
+			b.WriteRune(r)
+		case r > 0xffff:
+			high, low := utf16.EncodeRune(r)
+			fmt.Fprintf(b, `\u%04x\u%04x`, high, low)
+		default:
+			fmt.Fprintf(b, `\u%04x`, r)
+		}
+	}
+	b.WriteString(`"`)
+}
+
+// pythonFloat formats 'f' like python's float repr
+func pythonFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	case math.IsNaN(f):
+		return "NaN"
+	}
+
+	// python switches to scientific notation outside 1e-4 <= |f| < 1e16
+	if abs := math.Abs(f); abs != 0 && (abs < 1e-4 || abs >= 1e16) {
+		s := strconv.FormatFloat(f, 'e', -1, 64)
+		mantissa, exponent, _ := strings.Cut(s, "e")
+		sign := exponent[0]
+		exponent = strings.TrimLeft(exponent[1:], "0")
+		if len(exponent) < 2 {
+			exponent = strings.Repeat("0", 2-len(exponent)) + exponent
+		}
+		return fmt.Sprintf("%se%c%s", mantissa, sign, exponent)
+	}
+
+	s := strconv.FormatFloat(f, 'f', -1, 64)
+	if !strings.Contains(s, ".") {
+		s += ".0"
+	}
+	return s
+}
@@ -0,0 +1,266 @@
+package poetry
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/FollowTheProcess/venv/pkg/pyproject"
+	"github.com/spf13/afero"
+)
+
+// The hashes below were generated by poetry's own algorithm, any difference in
+// how we encode the content means every lock file would look stale
+const (
+	toolPoetryContent = `[tool.poetry]
+name = "demo"
+version = "0.1.0"
+description = "Démo"
+
+[tool.poetry.dependencies]
+python = "^3.10"
+requests = { version = "^2.28", extras = ["socks"], optional = true }
+click = "^8.1"
+
+[tool.poetry.group.test.dependencies]
+pytest = "^7.0"
+
+[tool.poetry.group.docs]
+optional = true
+
+[tool.poetry.group.docs.dependencies]
+mkdocs = { version = "^1.4", python = ">=3.10" }
+
+[tool.poetry.extras]
+socks = ["requests"]
+`
+	toolPoetryHash = "ea7a7804eb45c2dd9720c2cc5cfb9a299d1f6f2d82c3f6ab5af2808c60d71c9b"
+
+	projectContent = `[project]
+name = "demo"
+requires-python = ">=3.9"
+dependencies = ["httpx>=0.24", "naïve==1.0"]
+
+[project.optional-dependencies]
+cli = ["rich"]
+
+[tool.poetry.group.dev.dependencies]
+ruff = "*"
+`
+	projectHash = "8e19b1c08dbe061d647e529f120d28ebc52e5af17614e0e4f2e535c20f7ae8ef"
+)
+
+func TestContentHash(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "tool.poetry",
+			content: toolPoetryContent,
+			want:    toolPoetryHash,
+		},
+		{
+			name:    "project table",
+			content: projectContent,
+			want:    projectHash,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			py, err := pyproject.Parse([]byte(tt.content))
+			if err != nil {
+				t.Fatalf("could not parse pyproject.toml: %v", err)
+			}
+
+			got, err := ContentHash(py)
+			if err != nil {
+				t.Fatalf("ContentHash returned an error: %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("got %s, wanted %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodePython(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{value: nil, want: "null"},
+		{value: true, want: "true"},
+		{value: int64(42), want: "42"},
+		{value: 1.0, want: "1.0"},
+		{value: 0.25, want: "0.25"},
+		{value: 1e16, want: "1e+16"},
+		{value: 1e-5, want: "1e-05"},
+		{value: "quote \" and \\ and \t", want: `"quote \" and \\ and \t"`},
+		{value: "é and 🐍", want: `"\u00e9 and \ud83d\udc0d"`},
+		{value: []interface{}{"a", int64(1)}, want: `["a", 1]`},
+		{value: map[string]interface{}{"b": nil, "a": []interface{}{}}, want: `{"a": [], "b": null}`},
+	}
+
+	for _, tt := range tests {
+		var b strings.Builder
+		if err := encodePython(&b, tt.value); err != nil {
+			t.Fatalf("encodePython(%#v) returned an error: %v", tt.value, err)
+		}
+		if got := b.String(); got != tt.want {
+			t.Errorf("encodePython(%#v) = %s, wanted %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestStale(t *testing.T) {
+	tests := []struct {
+		name string
+		lock string // Contents of poetry.lock, empty for none
+		want bool
+	}{
+		{
+			name: "no lock file",
+			want: false,
+		},
+		{
+			name: "fresh",
+			lock: "[metadata]\nlock-version = \"2.0\"\ncontent-hash = \"" + toolPoetryHash + "\"\n",
+			want: false,
+		},
+		{
+			name: "stale",
+			lock: "[metadata]\nlock-version = \"2.0\"\ncontent-hash = \"" + projectHash + "\"\n",
+			want: true,
+		},
+		{
+			name: "no content hash",
+			lock: "[metadata]\nlock-version = \"2.0\"\n",
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			af := afero.Afero{Fs: afero.NewMemMapFs()}
+			if err := af.WriteFile("pyproject.toml", []byte(toolPoetryContent), 0o755); err != nil {
+				t.Fatalf("could not create file: %v", err)
+			}
+			if tt.lock != "" {
+				if err := af.WriteFile(lockFile, []byte(tt.lock), 0o755); err != nil {
+					t.Fatalf("could not create file: %v", err)
+				}
+			}
+
+			py, err := pyproject.Read(af)
+			if err != nil {
+				t.Fatalf("could not read pyproject.toml: %v", err)
+			}
+
+			got, err := Stale(af, py)
+			if err != nil {
+				t.Fatalf("Stale returned an error: %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("got %v, wanted %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetector_StaleLock(t *testing.T) {
+	stale := "[metadata]\ncontent-hash = \"" + projectHash + "\"\n"
+	confirm := func(question string) (bool, error) { return true, nil }
+
+	tests := []struct {
+		name     string
+		detector Detector
+		want     []string // Step descriptions
+	}{
+		{
+			name:     "warn only",
+			detector: Detector{},
+			want:     []string{"check for existing poetry environments", "poetry install"},
+		},
+		{
+			name:     "lock without asking",
+			detector: Detector{Lock: true, Confirm: confirm},
+			want:     []string{"check for existing poetry environments", "poetry lock", "poetry install"},
+		},
+		{
+			name:     "ask",
+			detector: Detector{Confirm: confirm},
+			want:     []string{"check for existing poetry environments", "poetry lock (if you choose to)", "poetry install"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			af := afero.Afero{Fs: afero.NewMemMapFs()}
+			if err := af.WriteFile("pyproject.toml", []byte(toolPoetryContent), 0o755); err != nil {
+				t.Fatalf("could not create file: %v", err)
+			}
+			if err := af.WriteFile(lockFile, []byte(stale), 0o755); err != nil {
+				t.Fatalf("could not create file: %v", err)
+			}
+
+			py, err := pyproject.Read(af)
+			if err != nil {
+				t.Fatalf("could not read pyproject.toml: %v", err)
+			}
+
+			match, err := tt.detector.Detect(af, py)
+			if err != nil {
+				t.Fatalf("Detect() returned an error: %v", err)
+			}
+
+			if len(match.Warnings) != 1 {
+				t.Errorf("expected a warning about the stale lock file, got %v", match.Warnings)
+			}
+
+			var got []string
+			for _, step := range match.Plan.Steps {
+				got = append(got, step.Description)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got steps %v, wanted %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLock(t *testing.T) {
+	tests := []struct {
+		testcase string
+		wantErr  bool
+	}{
+		{
+			testcase: "lock_poetry1",
+			wantErr:  false,
+		},
+		{
+			testcase: "lock_poetry2",
+			wantErr:  false,
+		},
+		{
+			testcase: "lock_no_version",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testcase, func(t *testing.T) {
+			setUp(tt.testcase)
+			defer tearDown()
+
+			if err := Lock(".", os.Stdout, os.Stderr); (err != nil) != tt.wantErr {
+				t.Errorf("Lock() error = %v, wantErr = %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package poetry
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/FollowTheProcess/venv/pkg/pyproject"
+)
+
+// Options are the choices passed on to poetry install, the zero value
+// is poetry's own default behaviour
+type Options struct {
+	With      []string // Optional dependency groups to install as well
+	Without   []string // Dependency groups to leave out
+	Extras    []string // Extras to install
+	AllExtras bool     // Install every extra
+	Sync      bool     // Remove anything from the environment that isn't in the lock file
+	NoRoot    bool     // Install only the dependencies, not the project itself
+}
+
+// Args returns the arguments to poetry for an install with these options
+func (o Options) Args() []string {
+	args := []string{"install"}
+	if len(o.With) != 0 {
+		args = append(args, "--with", strings.Join(o.With, ","))
+	}
+	if len(o.Without) != 0 {
+		args = append(args, "--without", strings.Join(o.Without, ","))
+	}
+	if o.AllExtras {
+		args = append(args, "--all-extras")
+	} else {
+		for _, extra := range o.Extras {
+			args = append(args, "--extras", extra)
+		}
+	}
+	if o.Sync {
+		args = append(args, "--sync")
+	}
+	if o.NoRoot {
+		args = append(args, "--no-root")
+	}
+
+	return args
+}
+
+// chosen reports whether the user has already chosen which groups or extras to install
+func (o Options) chosen() bool {
+	return len(o.With) != 0 || len(o.Without) != 0 || len(o.Extras) != 0 || o.AllExtras
+}
+
+// check returns an error if the options name groups or extras the project doesn't
+// declare, so a typo is caught with a clearer message than poetry's own
+func (o Options) check(groups, extras []string) error {
+	for _, group := range append(append([]string{}, o.With...), o.Without...) {
+		if !contains(groups, group) {
+			return fmt.Errorf("unknown dependency group %q, %s declares: %v", group, pyproject.File, groups)
+		}
+	}
+	if o.AllExtras {
+		return nil
+	}
+	for _, extra := range o.Extras {
+		if !contains(extras, extra) {
+			return fmt.Errorf("unknown extra %q, %s declares: %v", extra, pyproject.File, extras)
+		}
+	}
+
+	return nil
+}
+
+// poetryTool is the part of [tool.poetry] describing dependency groups and extras
+type poetryTool struct {
+	Group map[string]struct {
+		Optional bool `toml:"optional"`
+	} `toml:"group"`
+	Extras map[string][]string `toml:"extras"`
+}
+
+// Groups returns the names of the dependency groups under [tool.poetry.group]
+// in the project's pyproject.toml, and which of those are optional, both sorted
+func Groups(py *pyproject.PyProject) (groups, optional []string, err error) {
+	var tool poetryTool
+	if err := py.DecodeTool("poetry", &tool); err != nil {
+		return nil, nil, fmt.Errorf("%w", err)
+	}
+
+	for name, group := range tool.Group {
+		groups = append(groups, name)
+		if group.Optional {
+			optional = append(optional, name)
+		}
+	}
+	sort.Strings(groups)
+	sort.Strings(optional)
+
+	return groups, optional, nil
+}
+
+// Extras returns the names of the extras in the project's pyproject.toml, either
+// under [tool.poetry.extras] or, as poetry 2 supports, [project.optional-dependencies],
+// sorted
+func Extras(py *pyproject.PyProject) ([]string, error) {
+	var tool poetryTool
+	if err := py.DecodeTool("poetry", &tool); err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	extras := py.Extras()
+	for extra := range tool.Extras {
+		if !contains(extras, extra) {
+			extras = append(extras, extra)
+		}
+	}
+	sort.Strings(extras)
+
+	return extras, nil
+}
+
+// contains reports whether 'item' is in 'items'
+func contains(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,200 @@
+package poetry
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/FollowTheProcess/venv/pkg/project"
+	"github.com/FollowTheProcess/venv/pkg/pyproject"
+	"github.com/spf13/afero"
+)
+
+const groupsContent = `[build-system]
+build-backend = "poetry.core.masonry.api"
+
+[project.optional-dependencies]
+fast = ["orjson"]
+yaml = ["pyyaml"]
+
+[tool.poetry.extras]
+cli = ["click"]
+yaml = ["pyyaml"]
+
+[tool.poetry.group.test.dependencies]
+pytest = "^7.0"
+
+[tool.poetry.group.docs]
+optional = true
+
+[tool.poetry.group.docs.dependencies]
+mkdocs = "^1.4"
+`
+
+func TestOptions_Args(t *testing.T) {
+	tests := []struct {
+		name    string
+		options Options
+		want    []string
+	}{
+		{
+			name:    "default",
+			options: Options{},
+			want:    []string{"install"},
+		},
+		{
+			name:    "groups",
+			options: Options{With: []string{"docs", "lint"}, Without: []string{"test"}},
+			want:    []string{"install", "--with", "docs,lint", "--without", "test"},
+		},
+		{
+			name:    "extras",
+			options: Options{Extras: []string{"cli", "yaml"}},
+			want:    []string{"install", "--extras", "cli", "--extras", "yaml"},
+		},
+		{
+			name:    "all extras wins",
+			options: Options{Extras: []string{"cli"}, AllExtras: true},
+			want:    []string{"install", "--all-extras"},
+		},
+		{
+			name:    "sync and no root",
+			options: Options{Sync: true, NoRoot: true},
+			want:    []string{"install", "--sync", "--no-root"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.options.Args(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, wanted %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGroupsAndExtras(t *testing.T) {
+	py, err := pyproject.Parse([]byte(groupsContent))
+	if err != nil {
+		t.Fatalf("could not parse pyproject.toml: %v", err)
+	}
+
+	groups, optional, err := Groups(py)
+	if err != nil {
+		t.Fatalf("Groups returned an error: %v", err)
+	}
+	if want := []string{"docs", "test"}; !reflect.DeepEqual(groups, want) {
+		t.Errorf("got groups %v, wanted %v", groups, want)
+	}
+	if want := []string{"docs"}; !reflect.DeepEqual(optional, want) {
+		t.Errorf("got optional groups %v, wanted %v", optional, want)
+	}
+
+	extras, err := Extras(py)
+	if err != nil {
+		t.Fatalf("Extras returned an error: %v", err)
+	}
+	if want := []string{"cli", "fast", "yaml"}; !reflect.DeepEqual(extras, want) {
+		t.Errorf("got extras %v, wanted %v", extras, want)
+	}
+}
+
+func TestDetector_Options(t *testing.T) {
+	choose := func(optional, extras []string) ([]string, []string, error) {
+		return optional, extras[:1], nil
+	}
+
+	tests := []struct {
+		name    string
+		choose  Chooser
+		options Options
+		want    []string // Step descriptions
+		wantErr bool     // Whether the install step should fail
+	}{
+		{
+			name: "no prompt",
+			want: []string{"check for existing poetry environments", "poetry install"},
+		},
+		{
+			name:   "prompt",
+			choose: choose,
+			want: []string{
+				"check for existing poetry environments",
+				"choose optional dependency groups and extras",
+				"poetry install",
+			},
+		},
+		{
+			name:    "already chosen",
+			choose:  choose,
+			options: Options{With: []string{"docs"}},
+			want:    []string{"check for existing poetry environments", "poetry install --with docs"},
+		},
+		{
+			name:    "unknown group",
+			options: Options{With: []string{"lint"}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown extra",
+			options: Options{Extras: []string{"toml"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			af := afero.Afero{Fs: afero.NewMemMapFs()}
+			py, err := pyproject.Parse([]byte(groupsContent))
+			if err != nil {
+				t.Fatalf("could not parse pyproject.toml: %v", err)
+			}
+
+			match, err := Detector{Choose: tt.choose, Options: tt.options}.Detect(af, py)
+			if err != nil {
+				t.Fatalf("Detect() returned an error: %v", err)
+			}
+
+			if tt.wantErr {
+				// Unknown names don't stop detection, only the install itself
+				install := match.Plan.Steps[len(match.Plan.Steps)-1]
+				if err := install.Run(t.TempDir(), nil, nil); err == nil {
+					t.Error("install step did not return an error")
+				}
+				return
+			}
+
+			var got []string
+			for _, step := range match.Plan.Steps {
+				got = append(got, step.Description)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got steps %v, wanted %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetector_Choose(t *testing.T) {
+	setUp("install_chosen")
+	defer tearDown()
+
+	py, err := pyproject.Parse([]byte(groupsContent))
+	if err != nil {
+		t.Fatalf("could not parse pyproject.toml: %v", err)
+	}
+
+	choose := func(optional, extras []string) ([]string, []string, error) {
+		return []string{"docs"}, []string{"yaml"}, nil
+	}
+
+	match, err := Detector{Choose: choose}.Detect(afero.Afero{Fs: afero.NewMemMapFs()}, py)
+	if err != nil {
+		t.Fatalf("Detect() returned an error: %v", err)
+	}
+
+	// Skip checking for existing environments, that's tested elsewhere
+	plan := project.Plan{Steps: match.Plan.Steps[1:]}
+	if err := plan.Execute(t.TempDir(), nil, nil); err != nil {
+		t.Errorf("plan failed: %v", err)
+	}
+}
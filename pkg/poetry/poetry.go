@@ -2,24 +2,38 @@
 package poetry
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
 
-	"github.com/pelletier/go-toml/v2"
+	"github.com/FollowTheProcess/venv/pkg/fingerprint"
+	"github.com/FollowTheProcess/venv/pkg/project"
+	"github.com/FollowTheProcess/venv/pkg/pyproject"
 	"github.com/spf13/afero"
 )
 
 var poetryCommand = exec.Command
 
-// If the build-backend says this, it's a valid poetry project
-const poetryMarker = "poetry.core.masonry.api"
+// inProjectEnv tells poetry to create it's virtual environment as .venv in the project
+const inProjectEnv = "POETRY_VIRTUALENVS_IN_PROJECT=true"
 
-type pyProjectTOML struct {
-	BuildSystem struct {
-		Requires     []string `toml:"requires"`
-		BuildBackend string   `toml:"build-backend"`
-	} `toml:"build-system"`
+// errOutOfProject is returned when poetry already has an environment for the project
+// somewhere other than the project itself, which it would carry on using
+var errOutOfProject = errors.New("poetry is already using an environment outside the project")
+
+// tool is everything that marks a project as managed by poetry, older projects
+// use the poetry.masonry.api backend from before poetry-core was split out
+var tool = fingerprint.Tool{
+	Name:      "poetry",
+	Backends:  []string{"poetry.core.masonry.api", "poetry.masonry.api"},
+	Requires:  []string{"poetry-core", "poetry"},
+	Tables:    []string{"poetry"},
+	LockFiles: []string{lockFile},
 }
 
 // newPoetryCmd returns an exec.Cmd configured with the parameters passed in
@@ -29,12 +43,19 @@ func newPoetryCommand(cwd string, stdout, stderr io.Writer, args []string) *exec
 	cmd.Stdout = stdout
 	cmd.Stderr = stderr
 
+	// A nil Env means inherit ours, so make that explicit before adding to it
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env, inProjectEnv)
+
 	return cmd
 }
 
-// Install calls poetry install
-func Install(cwd string, stdout, stderr io.Writer) error {
-	cmd := newPoetryCommand(cwd, stdout, stderr, []string{"install"})
+// Install calls poetry install with 'options', always creating the virtual
+// environment as .venv in the project
+func Install(cwd string, stdout, stderr io.Writer, options Options) error {
+	cmd := newPoetryCommand(cwd, stdout, stderr, options.Args())
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("could not create poetry environment: %w", err)
 	}
@@ -42,19 +63,174 @@ func Install(cwd string, stdout, stderr io.Writer) error {
 	return nil
 }
 
-// IsPoetryFile reads the contents of the toml file given by 'path' and
-// determines if this is a valid poetry pyproject.toml file
-func IsPoetryFile(af afero.Afero, path string) (bool, error) {
-	var pyToml pyProjectTOML
+// EnvPath returns the path to the virtual environment poetry uses for the
+// project in cwd, or an empty string if it doesn't have one yet
+func EnvPath(cwd string) (string, error) {
+	stdout := &bytes.Buffer{}
+	cmd := newPoetryCommand(cwd, stdout, io.Discard, []string{"env", "info", "--path"})
+	if err := cmd.Run(); err != nil {
+		// poetry exits non-zero if there is no environment
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", nil
+		}
+		return "", fmt.Errorf("could not get poetry environment: %w", err)
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// CheckEnv returns an error if poetry already has an environment for the project
+// in cwd outside of the project (e.g. in poetry's cache directory), as poetry
+// would keep using it rather than creating a .venv in the project
+func CheckEnv(cwd string, stdout, stderr io.Writer) error {
+	path, err := EnvPath(cwd)
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+	if path == "" {
+		return nil
+	}
+
+	root, err := filepath.Abs(cwd)
+	if err != nil {
+		return fmt.Errorf("could not resolve %s: %w", cwd, err)
+	}
+
+	// Compare real paths so a symlinked project directory isn't mistaken for somewhere else
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+
+	rel, err := filepath.Rel(root, path)
+	if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil
+	}
+
+	return fmt.Errorf("%w (%s), remove it with 'poetry env remove --all' so a .venv can be created in the project", errOutOfProject, path)
+}
+
+// IsPoetryProject reports whether the project's build-backend is poetry, see
+// Detector for projects using poetry with a different build-backend
+func IsPoetryProject(py *pyproject.PyProject) bool {
+	for _, backend := range tool.Backends {
+		if py.Backend() == backend {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Chooser asks the user which of the project's 'optional' dependency groups
+// and 'extras' to install
+type Chooser func(optional, extras []string) (groups, chosen []string, err error)
 
-	data, err := af.ReadFile(path)
+// Detector recognises projects managed by poetry, whether it's their build-backend
+// or just used as a tool
+type Detector struct {
+	Choose  Chooser                             // Asks which optional groups and extras to install when building, nil to never ask
+	Confirm func(question string) (bool, error) // Asks whether to update a stale poetry.lock, nil to never ask
+	Options Options                             // The user's choices for poetry install
+	Lock    bool                                // Update a stale poetry.lock without asking
+}
+
+// Name implements project.Detector
+func (Detector) Name() string {
+	return "poetry"
+}
+
+// Detect implements project.Detector
+func (d Detector) Detect(fs afero.Afero, py *pyproject.PyProject) (project.Match, error) {
+	verdict, err := fingerprint.Fingerprint(fs, py, tool)
 	if err != nil {
-		return false, fmt.Errorf("could not read %s: %w", path, err)
+		return project.Match{}, fmt.Errorf("%w", err)
+	}
+	if verdict.Confidence == project.None {
+		return project.Match{}, nil
 	}
 
-	if err := toml.Unmarshal(data, &pyToml); err != nil {
-		return false, fmt.Errorf("could not unmarshall toml data: %w", err)
+	groups, optional, err := Groups(py)
+	if err != nil {
+		return project.Match{}, fmt.Errorf("%w", err)
 	}
+	extras, err := Extras(py)
+	if err != nil {
+		return project.Match{}, fmt.Errorf("%w", err)
+	}
+
+	options := d.Options
+	steps := []project.Step{{Description: "check for existing poetry environments", Run: CheckEnv, Phase: project.Build}}
+
+	// Only ask if there's something to choose and the user hasn't already chosen
+	if d.Choose != nil && !options.chosen() && len(optional)+len(extras) != 0 {
+		steps = append(steps, project.Step{
+			Description: "choose optional dependency groups and extras",
+			Run: func(cwd string, stdout, stderr io.Writer) error {
+				with, chosen, err := d.Choose(optional, extras)
+				if err != nil {
+					return fmt.Errorf("%w", err)
+				}
+				options.With = with
+				options.Extras = chosen
+				return nil
+			},
+			Phase: project.Build,
+		})
+	}
+
+	// An out of date lock file makes poetry install fail part way through or
+	// install outdated pins, so say so up front and offer to fix it
+	stale, err := Stale(fs, py)
+	if err != nil {
+		return project.Match{}, fmt.Errorf("%w", err)
+	}
+	var warnings []string
+	if stale {
+		warnings = append(warnings, fmt.Sprintf("%s is out of date with %s, run 'poetry lock' to fix it ('poetry lock --no-update' before poetry 2)", lockFile, pyproject.File))
+		switch {
+		case d.Lock:
+			steps = append(steps, project.Step{Description: "poetry lock", Run: Lock})
+		case d.Confirm != nil:
+			steps = append(steps, project.Step{
+				Description: "poetry lock (if you choose to)",
+				Run: func(cwd string, stdout, stderr io.Writer) error {
+					update, err := d.Confirm(fmt.Sprintf("Update %s with 'poetry lock' before installing?", lockFile))
+					if err != nil {
+						return fmt.Errorf("%w", err)
+					}
+					if !update {
+						return nil
+					}
+					return Lock(cwd, stdout, stderr)
+				},
+			})
+		}
+	}
+
+	steps = append(steps, project.Step{
+		Description: fmt.Sprintf("poetry %s", strings.Join(options.Args(), " ")),
+		Run: func(cwd string, stdout, stderr io.Writer) error {
+			// Only the user's own choices need checking and only now poetry has been
+			// picked, the extras are shared with other detectors so may not be meant for it
+			if err := d.Options.check(groups, extras); err != nil {
+				return fmt.Errorf("%w", err)
+			}
+			return Install(cwd, stdout, stderr, options)
+		},
+	})
 
-	return pyToml.BuildSystem.BuildBackend == poetryMarker, nil
+	return project.Match{
+		Confidence: verdict.Confidence,
+		Reasons:    verdict.Evidence,
+		Warnings:   warnings,
+		Plan: project.Plan{
+			Summary: fmt.Sprintf("Found %q specifying poetry. Installing...", pyproject.File),
+			Steps:   steps,
+			Tools:   []string{"poetry"},
+		},
+	}, nil
 }
@@ -1,12 +1,16 @@
 package poetry
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"reflect"
 	"testing"
 
+	"github.com/FollowTheProcess/venv/pkg/project"
+	"github.com/FollowTheProcess/venv/pkg/pyproject"
 	"github.com/spf13/afero"
 )
 
@@ -82,11 +86,60 @@ func TestPoetryHelperProcess(t *testing.T) {
 	// First separate the go test args from what we actually want
 	args := extractCmdArgs(os.Args)
 
+	// Every poetry command must create it's environment in the project
+	if os.Getenv("POETRY_VIRTUALENVS_IN_PROJECT") != "true" {
+		fmt.Fprintf(os.Stderr, "Error: POETRY_VIRTUALENVS_IN_PROJECT not set")
+		os.Exit(1)
+	}
+
 	switch os.Getenv("POETRY_TEST_CASE") {
 	case "install_success":
 		expectedArgs := []string{"poetry", "install"}
 		assertCorrectArgs(expectedArgs, args)
 
+	case "env_none":
+		assertCorrectArgs([]string{"poetry", "env", "info", "--path"}, args)
+		os.Exit(1)
+
+	case "env_in_project":
+		assertCorrectArgs([]string{"poetry", "env", "info", "--path"}, args)
+		cwd, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v", err)
+			os.Exit(1)
+		}
+		fmt.Fprintln(os.Stdout, filepath.Join(cwd, ".venv"))
+
+	case "env_out_of_project":
+		assertCorrectArgs([]string{"poetry", "env", "info", "--path"}, args)
+		fmt.Fprintln(os.Stdout, "/home/me/.cache/pypoetry/virtualenvs/demo-Xyz123-py3.10")
+
+	case "install_options":
+		expectedArgs := []string{"poetry", "install", "--with", "docs", "--extras", "cli", "--sync", "--no-root"}
+		assertCorrectArgs(expectedArgs, args)
+
+	case "install_chosen":
+		expectedArgs := []string{"poetry", "install", "--with", "docs", "--extras", "yaml"}
+		assertCorrectArgs(expectedArgs, args)
+
+	case "lock_poetry1":
+		if reflect.DeepEqual(args, []string{"poetry", "--version"}) {
+			fmt.Fprintln(os.Stdout, "Poetry version 1.1.13")
+			os.Exit(0)
+		}
+		assertCorrectArgs([]string{"poetry", "lock", "--no-update"}, args)
+
+	case "lock_poetry2":
+		if reflect.DeepEqual(args, []string{"poetry", "--version"}) {
+			fmt.Fprintln(os.Stdout, "Poetry (version 2.0.1)")
+			os.Exit(0)
+		}
+		// --no-update was removed in poetry 2
+		assertCorrectArgs([]string{"poetry", "lock"}, args)
+
+	case "lock_no_version":
+		fmt.Fprintln(os.Stdout, "not poetry")
+
 	case "install_error":
 		// Simulate failure by printing to stderr and exit 1
 		fmt.Fprintf(os.Stderr, "something wrong")
@@ -97,12 +150,18 @@ func TestPoetryHelperProcess(t *testing.T) {
 func TestInstall(t *testing.T) {
 	tests := []struct {
 		testcase string
+		options  Options
 		wantErr  bool
 	}{
 		{
 			testcase: "install_success",
 			wantErr:  false,
 		},
+		{
+			testcase: "install_options",
+			options:  Options{With: []string{"docs"}, Extras: []string{"cli"}, Sync: true, NoRoot: true},
+			wantErr:  false,
+		},
 		{
 			testcase: "install_error",
 			wantErr:  true,
@@ -114,14 +173,45 @@ func TestInstall(t *testing.T) {
 			setUp(tt.testcase)
 			defer tearDown()
 
-			if err := Install(".", os.Stdout, os.Stderr); (err != nil) != tt.wantErr {
+			if err := Install(".", os.Stdout, os.Stderr, tt.options); (err != nil) != tt.wantErr {
 				t.Errorf("Install() error = %v, wantErr = %v", err, tt.wantErr)
 			}
 		})
 	}
 }
 
-func TestIsPoetryFile(t *testing.T) {
+func TestCheckEnv(t *testing.T) {
+	tests := []struct {
+		wantErr  error
+		testcase string
+	}{
+		{
+			testcase: "env_none",
+			wantErr:  nil,
+		},
+		{
+			testcase: "env_in_project",
+			wantErr:  nil,
+		},
+		{
+			testcase: "env_out_of_project",
+			wantErr:  errOutOfProject,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testcase, func(t *testing.T) {
+			setUp(tt.testcase)
+			defer tearDown()
+
+			if err := CheckEnv(t.TempDir(), os.Stdout, os.Stderr); !errors.Is(err, tt.wantErr) {
+				t.Errorf("CheckEnv() error = %v, wanted %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIsPoetryProject(t *testing.T) {
 	t.Run("true if content is there", func(t *testing.T) {
 		af := afero.Afero{Fs: afero.NewMemMapFs()}
 
@@ -135,11 +225,13 @@ func TestIsPoetryFile(t *testing.T) {
 			t.Fatalf("could not create file: %v", err)
 		}
 
-		got, err := IsPoetryFile(af, "pyproject.toml")
+		py, err := pyproject.Read(af)
 		if err != nil {
-			t.Errorf("IsPoetryFile returned an error: %v", err)
+			t.Fatalf("could not read pyproject.toml: %v", err)
 		}
 
+		got := IsPoetryProject(py)
+
 		if got != true {
 			t.Errorf("got %v, wanted true", got)
 		}
@@ -158,11 +250,13 @@ func TestIsPoetryFile(t *testing.T) {
 			t.Fatalf("could not create file: %v", err)
 		}
 
-		got, err := IsPoetryFile(af, "pyproject.toml")
+		py, err := pyproject.Read(af)
 		if err != nil {
-			t.Errorf("IsPoetryFile returned an error: %v", err)
+			t.Fatalf("could not read pyproject.toml: %v", err)
 		}
 
+		got := IsPoetryProject(py)
+
 		if got != false {
 			t.Errorf("got %v, wanted false", got)
 		}
@@ -177,13 +271,79 @@ func TestIsPoetryFile(t *testing.T) {
 			t.Fatalf("could not create file: %v", err)
 		}
 
-		got, err := IsPoetryFile(af, "pyproject.toml")
+		py, err := pyproject.Read(af)
 		if err != nil {
-			t.Errorf("IsFlitFile returned an error: %v", err)
+			t.Fatalf("could not read pyproject.toml: %v", err)
 		}
 
+		got := IsPoetryProject(py)
+
 		if got != false {
 			t.Errorf("got %v, wanted false", got)
 		}
 	})
 }
+
+func TestDetector_Detect(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		confidence project.Confidence
+		write      bool
+	}{
+		{
+			name:       "no pyproject.toml",
+			write:      false,
+			confidence: project.None,
+		},
+		{
+			name:       "poetry pyproject.toml",
+			content:    "[build-system]\nbuild-backend = \"poetry.core.masonry.api\"\n",
+			write:      true,
+			confidence: project.High,
+		},
+		{
+			name:       "legacy poetry backend",
+			content:    "[build-system]\nbuild-backend = \"poetry.masonry.api\"\n",
+			write:      true,
+			confidence: project.High,
+		},
+		{
+			name:       "poetry as a tool with another backend",
+			content:    "[build-system]\nbuild-backend = \"hatchling.build\"\n\n[tool.poetry]\nname = \"demo\"\n",
+			write:      true,
+			confidence: project.Medium,
+		},
+		{
+			name:       "other pyproject.toml",
+			content:    "[build-system]\nbuild-backend = \"something else\"\n",
+			write:      true,
+			confidence: project.None,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			af := afero.Afero{Fs: afero.NewMemMapFs()}
+			if tt.write {
+				if err := af.WriteFile("pyproject.toml", []byte(tt.content), 0o755); err != nil {
+					t.Fatalf("could not create file: %v", err)
+				}
+			}
+
+			py, err := pyproject.Read(af)
+			if err != nil {
+				t.Fatalf("could not read pyproject.toml: %v", err)
+			}
+
+			got, err := Detector{}.Detect(af, py)
+			if err != nil {
+				t.Fatalf("Detect() returned an error: %v", err)
+			}
+
+			if got.Confidence != tt.confidence {
+				t.Errorf("got confidence %v, wanted %v", got.Confidence, tt.confidence)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+// Package project defines how venv recognises the different kinds of python project
+// and the plan it follows to build an environment for each of them
+//
+// Each project type (requirements files, setuptools, poetry, flit etc.) lives in it's own
+// package and exposes a Detector, the CLI then asks a Registry of detectors which one
+// best matches the project in front of it and executes the resulting Plan
+package project
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/FollowTheProcess/venv/pkg/pyproject"
+	"github.com/spf13/afero"
+)
+
+// Confidence describes how sure a Detector is that it recognises a project
+type Confidence int
+
+const (
+	None   Confidence = iota // The detector does not recognise the project at all
+	Low                      // There are hints, but the project could be something else
+	Medium                   // The project probably matches
+	High                     // The project unambiguously matches
+)
+
+// String implements fmt.Stringer for a Confidence
+func (c Confidence) String() string {
+	switch c {
+	case None:
+		return "none"
+	case Low:
+		return "low"
+	case Medium:
+		return "medium"
+	case High:
+		return "high"
+	default:
+		return fmt.Sprintf("Confidence(%d)", int(c))
+	}
+}
+
+// StepFunc is the signature of a single action in a Plan, it has the same shape
+// as the wrappers in pkg/python so those can be used directly
+type StepFunc func(cwd string, stdout, stderr io.Writer) error
+
+// Phase says when a Step runs, building a new environment or syncing an existing one
+type Phase int
+
+const (
+	Always Phase = iota // The step runs when building and when syncing
+	Build               // The step only runs when building a new environment e.g. creating it
+	Sync                // The step only runs when syncing an existing environment
+)
+
+// Step is a single, described action in a Plan
+type Step struct {
+	Description string   // Human readable description of what the step does
+	Run         StepFunc // The action itself
+	Phase       Phase    // When the step runs, the zero value means always
+}
+
+// Plan is an ordered set of steps which together build a project's environment
+type Plan struct {
+	Summary string   // Message shown to the user before the plan is executed
+	Steps   []Step   // The steps to run, in order
+	Tools   []string // Executables the steps need on $PATH e.g. "poetry", checked before running
+}
+
+// Execute builds a new environment by running each step in the plan in order,
+// skipping those only needed when syncing and stopping at the first error
+func (p Plan) Execute(cwd string, stdout, stderr io.Writer) error {
+	return p.run(cwd, stdout, stderr, Sync)
+}
+
+// Sync re-runs the plan against an existing environment, skipping the steps
+// only needed when building a new one and stopping at the first error
+func (p Plan) Sync(cwd string, stdout, stderr io.Writer) error {
+	return p.run(cwd, stdout, stderr, Build)
+}
+
+// run runs every step in the plan not in the 'skip' phase
+func (p Plan) run(cwd string, stdout, stderr io.Writer, skip Phase) error {
+	for _, step := range p.Steps {
+		if step.Phase == skip {
+			continue
+		}
+		if err := step.Run(cwd, stdout, stderr); err != nil {
+			return fmt.Errorf("%w", err)
+		}
+	}
+
+	return nil
+}
+
+// Match is the result of a Detector inspecting a project
+type Match struct {
+	Detector   string     // Name of the detector that produced the match
+	Confidence Confidence // How sure the detector is
+	Reasons    []string   // The evidence the detector used to reach it's verdict
+	Warnings   []string   // Problems with the project worth telling the user about, that don't stop the plan
+	Plan       Plan       // What to do to build the environment
+}
+
+// Found reports whether the match recognised the project at all
+func (m Match) Found() bool {
+	return m.Confidence > None
+}
+
+// Detector is the interface every project type implements
+type Detector interface {
+	// Name returns the name of the project type e.g. "poetry"
+	Name() string
+
+	// Detect inspects the filesystem and the project's pyproject.toml ('py', nil if
+	// there isn't one) and returns a Match, a Match with a Confidence of None means
+	// the detector does not recognise the project
+	Detect(fs afero.Afero, py *pyproject.PyProject) (Match, error)
+}
+
+// entry is a registered detector along with it's priority
+type entry struct {
+	detector Detector
+	priority int
+}
+
+// Registry holds a set of detectors and decides which one best matches a project
+type Registry struct {
+	entries []entry
+}
+
+// NewRegistry creates and returns an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds a detector to the registry, detectors with a higher priority
+// are consulted first and win ties in confidence
+func (r *Registry) Register(priority int, d Detector) {
+	r.entries = append(r.entries, entry{detector: d, priority: priority})
+	sort.SliceStable(r.entries, func(i, j int) bool {
+		return r.entries[i].priority > r.entries[j].priority
+	})
+}
+
+// Detectors returns the registered detectors in priority order
+func (r *Registry) Detectors() []Detector {
+	detectors := make([]Detector, 0, len(r.entries))
+	for _, e := range r.entries {
+		detectors = append(detectors, e.detector)
+	}
+
+	return detectors
+}
+
+// Detect asks every registered detector about the project and returns the match
+// with the highest confidence, ties are broken by priority
+//
+// The project's pyproject.toml is parsed once here and the result shared by
+// every detector. If no detector recognises the project, the returned Match
+// will have a Confidence of None
+func (r *Registry) Detect(fs afero.Afero) (Match, error) {
+	py, err := pyproject.Read(fs)
+	if err != nil {
+		return Match{}, fmt.Errorf("%w", err)
+	}
+
+	return r.DetectWith(fs, py)
+}
+
+// DetectWith is Detect for a caller that has already parsed the project's
+// pyproject.toml, 'py' is nil if the project doesn't have one
+func (r *Registry) DetectWith(fs afero.Afero, py *pyproject.PyProject) (Match, error) {
+	var best Match
+	for _, e := range r.entries {
+		match, err := e.detector.Detect(fs, py)
+		if err != nil {
+			return Match{}, fmt.Errorf("%s detector: %w", e.detector.Name(), err)
+		}
+		if match.Detector == "" {
+			match.Detector = e.detector.Name()
+		}
+		if match.Confidence > best.Confidence {
+			best = match
+		}
+	}
+
+	return best, nil
+}
@@ -0,0 +1,236 @@
+package project
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/FollowTheProcess/venv/pkg/pyproject"
+	"github.com/spf13/afero"
+)
+
+// fakeDetector is a Detector that returns a canned match, if 'backend' is set
+// it only matches projects with that build-backend
+type fakeDetector struct {
+	err        error
+	name       string
+	backend    string
+	confidence Confidence
+}
+
+func (f fakeDetector) Name() string {
+	return f.name
+}
+
+func (f fakeDetector) Detect(fs afero.Afero, py *pyproject.PyProject) (Match, error) {
+	if f.err != nil {
+		return Match{}, f.err
+	}
+	if f.backend != "" && py.Backend() != f.backend {
+		return Match{}, nil
+	}
+	return Match{Confidence: f.confidence}, nil
+}
+
+func TestRegistry_Detectors(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(1, fakeDetector{name: "low"})
+	registry.Register(10, fakeDetector{name: "high"})
+	registry.Register(5, fakeDetector{name: "middle"})
+
+	var got []string
+	for _, d := range registry.Detectors() {
+		got = append(got, d.Name())
+	}
+
+	want := []string{"high", "middle", "low"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, wanted %v", got, want)
+	}
+}
+
+func TestRegistry_Detect(t *testing.T) {
+	tests := []struct {
+		name       string
+		detectors  map[int]Detector
+		want       string
+		confidence Confidence
+		wantErr    bool
+	}{
+		{
+			name:       "empty registry",
+			detectors:  map[int]Detector{},
+			want:       "",
+			confidence: None,
+			wantErr:    false,
+		},
+		{
+			name: "nothing matches",
+			detectors: map[int]Detector{
+				1: fakeDetector{name: "one"},
+				2: fakeDetector{name: "two"},
+			},
+			want:       "",
+			confidence: None,
+			wantErr:    false,
+		},
+		{
+			name: "highest confidence wins",
+			detectors: map[int]Detector{
+				1: fakeDetector{name: "sure", confidence: High},
+				2: fakeDetector{name: "unsure", confidence: Low},
+			},
+			want:       "sure",
+			confidence: High,
+			wantErr:    false,
+		},
+		{
+			name: "priority breaks ties",
+			detectors: map[int]Detector{
+				1: fakeDetector{name: "first", confidence: High},
+				2: fakeDetector{name: "second", confidence: High},
+			},
+			want:       "second",
+			confidence: High,
+			wantErr:    false,
+		},
+		{
+			name: "errors are propagated",
+			detectors: map[int]Detector{
+				1: fakeDetector{name: "fine", confidence: High},
+				2: fakeDetector{name: "broken", err: errors.New("bang")},
+			},
+			want:       "",
+			confidence: None,
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewRegistry()
+			for priority, detector := range tt.detectors {
+				registry.Register(priority, detector)
+			}
+
+			got, err := registry.Detect(afero.Afero{Fs: afero.NewMemMapFs()})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Detect() error = %v, wantErr = %v", err, tt.wantErr)
+			}
+
+			if got.Detector != tt.want {
+				t.Errorf("got detector %q, wanted %q", got.Detector, tt.want)
+			}
+
+			if got.Confidence != tt.confidence {
+				t.Errorf("got confidence %v, wanted %v", got.Confidence, tt.confidence)
+			}
+		})
+	}
+}
+
+func TestPlan_Execute(t *testing.T) {
+	t.Run("runs every step in order", func(t *testing.T) {
+		var ran []string
+		record := func(name string) StepFunc {
+			return func(cwd string, stdout, stderr io.Writer) error {
+				ran = append(ran, name)
+				return nil
+			}
+		}
+
+		plan := Plan{Steps: []Step{{Run: record("one")}, {Run: record("two")}}}
+		if err := plan.Execute(".", io.Discard, io.Discard); err != nil {
+			t.Fatalf("Execute() returned an error: %v", err)
+		}
+
+		want := []string{"one", "two"}
+		if !reflect.DeepEqual(ran, want) {
+			t.Errorf("got %v, wanted %v", ran, want)
+		}
+	})
+
+	t.Run("stops at first error", func(t *testing.T) {
+		called := false
+		plan := Plan{
+			Steps: []Step{
+				{Run: func(cwd string, stdout, stderr io.Writer) error { return errors.New("bang") }},
+				{Run: func(cwd string, stdout, stderr io.Writer) error { called = true; return nil }},
+			},
+		}
+
+		if err := plan.Execute(".", io.Discard, io.Discard); err == nil {
+			t.Error("Execute() did not return an error")
+		}
+
+		if called {
+			t.Error("Execute() ran a step after one had failed")
+		}
+	})
+}
+
+func TestPlan_Phases(t *testing.T) {
+	var ran []string
+	record := func(name string) StepFunc {
+		return func(cwd string, stdout, stderr io.Writer) error {
+			ran = append(ran, name)
+			return nil
+		}
+	}
+
+	plan := Plan{
+		Steps: []Step{
+			{Run: record("create"), Phase: Build},
+			{Run: record("update"), Phase: Sync},
+			{Run: record("install")},
+		},
+	}
+
+	if err := plan.Execute(".", io.Discard, io.Discard); err != nil {
+		t.Fatalf("Execute() returned an error: %v", err)
+	}
+	if want := []string{"create", "install"}; !reflect.DeepEqual(ran, want) {
+		t.Errorf("Execute() ran %v, wanted %v", ran, want)
+	}
+
+	ran = nil
+	if err := plan.Sync(".", io.Discard, io.Discard); err != nil {
+		t.Fatalf("Sync() returned an error: %v", err)
+	}
+	if want := []string{"update", "install"}; !reflect.DeepEqual(ran, want) {
+		t.Errorf("Sync() ran %v, wanted %v", ran, want)
+	}
+}
+
+func TestRegistry_Detect_PyProject(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(1, fakeDetector{name: "hatch", backend: "hatchling.build", confidence: High})
+
+	t.Run("parsed and shared", func(t *testing.T) {
+		af := afero.Afero{Fs: afero.NewMemMapFs()}
+		content := "[build-system]\nbuild-backend = \"hatchling.build\"\n"
+		if err := af.WriteFile(pyproject.File, []byte(content), 0o755); err != nil {
+			t.Fatalf("could not create file: %v", err)
+		}
+
+		got, err := registry.Detect(af)
+		if err != nil {
+			t.Fatalf("Detect() returned an error: %v", err)
+		}
+		if got.Detector != "hatch" {
+			t.Errorf("got detector %q, wanted hatch", got.Detector)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		af := afero.Afero{Fs: afero.NewMemMapFs()}
+		if err := af.WriteFile(pyproject.File, []byte("[build-system"), 0o755); err != nil {
+			t.Fatalf("could not create file: %v", err)
+		}
+
+		if _, err := registry.Detect(af); err == nil {
+			t.Error("Detect() did not return an error for an invalid pyproject.toml")
+		}
+	})
+}
@@ -0,0 +1,284 @@
+package pyproject
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// contextLines is how many lines before the offending one are shown in a snippet
+const contextLines = 2
+
+var (
+	// go-toml reports redefinitions without a position, so we find them ourselves
+	duplicateTable = regexp.MustCompile(`table (\S+) already exists`)
+	duplicateKey   = regexp.MustCompile(`key (\S+) is already defined`)
+
+	// versionLike matches an unquoted value that looks like a version or version specifier
+	versionLike = regexp.MustCompile(`^(\^|~=?|[<>]=?|==|!=)?\s*\d+(\.(\d+|\*))*`)
+)
+
+// ParseError is a pyproject.toml that could not be parsed, along with where the
+// problem is and, for common mistakes, a hint on how to fix it
+type ParseError struct {
+	Err     error  // The underlying error from the toml parser
+	Message string // What went wrong, without the parser's prefix
+	Snippet string // The offending lines with a caret under the problem, empty if the position is unknown
+	Hint    string // How to fix a common mistake, empty if there isn't one
+	Line    int    // 1 based line number, 0 if unknown
+	Column  int    // 1 based column number, 0 if unknown
+}
+
+// Error implements error, giving the position in the style of a compiler
+// e.g. "pyproject.toml:3:14: float can have at most one decimal point"
+func (e *ParseError) Error() string {
+	switch {
+	case e.Line != 0 && e.Column != 0:
+		return fmt.Sprintf("%s:%d:%d: %s", File, e.Line, e.Column, e.Message)
+	case e.Line != 0:
+		return fmt.Sprintf("%s:%d: %s", File, e.Line, e.Message)
+	default:
+		return fmt.Sprintf("%s: %s", File, e.Message)
+	}
+}
+
+// Unwrap returns the underlying parser error
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// newParseError builds a ParseError from the error 'err' returned by the toml
+// parser for the document 'data'
+func newParseError(data []byte, err error) *ParseError {
+	parseErr := &ParseError{Err: err, Message: strings.TrimPrefix(err.Error(), "toml: ")}
+	lines := strings.Split(string(data), "\n")
+
+	var table string
+	var decodeErr *toml.DecodeError
+	if errors.As(err, &decodeErr) {
+		parseErr.Line, parseErr.Column = decodeErr.Position()
+	} else {
+		parseErr.Line, parseErr.Column, table = locate(lines, parseErr.Message)
+	}
+
+	// go-toml only names the last part of a redefined dotted table e.g. "poetry"
+	// for [tool.poetry], so give it it's full name
+	if table != "" && duplicateTable.MatchString(parseErr.Message) {
+		parseErr.Message = fmt.Sprintf("table %s already exists", table)
+	}
+
+	if parseErr.Line > 0 && parseErr.Line <= len(lines) {
+		parseErr.Snippet = snippet(lines, parseErr.Line, parseErr.Column)
+		parseErr.Hint = hint(lines[parseErr.Line-1], parseErr.Message, table)
+	} else {
+		parseErr.Hint = hint("", parseErr.Message, table)
+	}
+
+	return parseErr
+}
+
+// locate finds the position of a redefined table or key, which go-toml reports
+// without one and by only the last part of it's name, by looking for the second
+// definition. It also returns the full dotted name of the table involved
+func locate(lines []string, message string) (line, column int, table string) {
+	if match := duplicateTable.FindStringSubmatch(message); match != nil {
+		return secondTable(lines, match[1])
+	}
+
+	if match := duplicateKey.FindStringSubmatch(message); match != nil {
+		return secondKey(lines, match[1])
+	}
+
+	return 0, 0, ""
+}
+
+// secondTable returns the position of the first table header ending in 'name'
+// to be declared twice, along with the table's full dotted name
+func secondTable(lines []string, name string) (line, column int, table string) {
+	seen := make(map[string]bool)
+	for i, l := range scan(lines) {
+		if l.header == nil || l.array || l.header[len(l.header)-1] != name {
+			continue
+		}
+		path := strings.Join(l.header, ".")
+		if seen[path] {
+			return i + 1, l.column, path
+		}
+		seen[path] = true
+	}
+
+	return 0, 0, ""
+}
+
+// secondKey returns the position of the first key ending in 'name' to appear
+// twice in the same table, along with that table's full dotted name
+func secondKey(lines []string, name string) (line, column int, table string) {
+	seen := make(map[string]bool)
+	section := 0 // Each header starts a new table, even [[array]] headers with the same name
+	current := ""
+	for i, l := range scan(lines) {
+		if l.header != nil {
+			section++
+			current = strings.Join(l.header, ".")
+			continue
+		}
+		if l.key == nil || l.key[len(l.key)-1] != name {
+			continue
+		}
+		id := fmt.Sprintf("%d %s", section, strings.Join(l.key, "."))
+		if seen[id] {
+			return i + 1, l.column, current
+		}
+		seen[id] = true
+	}
+
+	return 0, 0, ""
+}
+
+// scanned is a line of a TOML document, either a table header, a key/value
+// pair or neither (blank lines, comments and the inside of multi-line strings)
+type scanned struct {
+	header []string // The parts of a table header's name, nil if not a header
+	key    []string // The parts of a key's name, nil if not a key/value pair
+	array  bool     // Whether the header is an [[array]] of tables
+	column int      // 1 based column the header or key starts at
+}
+
+// scan does just enough parsing of 'lines' to find table headers and keys, for
+// locating errors in a document go-toml has already rejected
+func scan(lines []string) []scanned {
+	out := make([]scanned, len(lines))
+	multiline := ""
+	for i, l := range lines {
+		trimmed := strings.TrimSpace(l)
+		if multiline != "" {
+			if strings.Count(l, multiline)%2 == 1 {
+				multiline = ""
+			}
+			continue
+		}
+		column := strings.Index(l, trimmed) + 1
+
+		switch {
+		case trimmed == "", strings.HasPrefix(trimmed, "#"):
+		case strings.HasPrefix(trimmed, "[["):
+			name, _, _ := strings.Cut(strings.TrimPrefix(trimmed, "[["), "]]")
+			out[i] = scanned{header: splitKey(name), array: true, column: column}
+		case strings.HasPrefix(trimmed, "["):
+			name, _, _ := strings.Cut(strings.TrimPrefix(trimmed, "["), "]")
+			out[i] = scanned{header: splitKey(name), column: column}
+		default:
+			key, value, found := strings.Cut(trimmed, "=")
+			if !found {
+				continue
+			}
+			out[i] = scanned{key: splitKey(key), column: column}
+			for _, quote := range []string{`"""`, "'''"} {
+				if strings.Count(value, quote)%2 == 1 {
+					multiline = quote
+				}
+			}
+		}
+	}
+
+	return out
+}
+
+// splitKey splits a dotted TOML key e.g. 'tool . "poetry"' into it's parts
+// e.g. ["tool", "poetry"], dots inside quotes are part of the name
+func splitKey(key string) []string {
+	var parts []string
+	var part strings.Builder
+	quote := rune(0)
+	for _, r := range key {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			part.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '.':
+			parts = append(parts, strings.TrimSpace(part.String()))
+			part.Reset()
+		default:
+			part.WriteRune(r)
+		}
+	}
+
+	return append(parts, strings.TrimSpace(part.String()))
+}
+
+// snippet renders the 1 based 'line' of 'lines' with a few lines of context
+// before it and, if 'column' is known, a caret under the offending character
+func snippet(lines []string, line, column int) string {
+	first := line - contextLines
+	if first < 1 {
+		first = 1
+	}
+	width := len(strconv.Itoa(line))
+
+	var b strings.Builder
+	for n := first; n <= line; n++ {
+		fmt.Fprintf(&b, "%*d | %s\n", width, n, lines[n-1])
+	}
+
+	if column > 0 {
+		// Keep any tabs so the caret lines up however they are displayed
+		var pad strings.Builder
+		for i, r := range lines[line-1] {
+			if i >= column-1 {
+				break
+			}
+			if r == '\t' {
+				pad.WriteRune('\t')
+			} else {
+				pad.WriteRune(' ')
+			}
+		}
+		fmt.Fprintf(&b, "%*s | %s^\n", width, "", pad.String())
+	}
+
+	return b.String()
+}
+
+// hint returns advice for the common mistakes that cause 'message' on the
+// offending 'line' in 'table' (if known), or an empty string if it's not one
+// we recognise
+func hint(line, message, table string) string {
+	switch {
+	case duplicateTable.MatchString(message):
+		if table == "" {
+			table = duplicateTable.FindStringSubmatch(message)[1]
+		}
+		return fmt.Sprintf("each table can only be declared once, merge the two [%s] tables together", table)
+
+	case duplicateKey.MatchString(message):
+		key := duplicateKey.FindStringSubmatch(message)[1]
+		if table == "" {
+			return fmt.Sprintf("each key can only appear once in a table, remove one of the %q keys", key)
+		}
+		return fmt.Sprintf("each key can only appear once in a table, remove one of the %q keys from [%s]", key, table)
+	}
+
+	if key, value, found := strings.Cut(line, "="); found {
+		value = strings.TrimSpace(value)
+		if !strings.HasPrefix(value, `"`) && !strings.HasPrefix(value, "'") && versionLike.MatchString(value) {
+			return fmt.Sprintf("versions are strings in TOML so must be quoted e.g. %s = %q", strings.TrimSpace(key), value)
+		}
+	}
+
+	if strings.HasPrefix(strings.TrimSpace(line), "[") && strings.Contains(message, "expected character ]") {
+		return "table headers need a closing bracket e.g. [project]"
+	}
+
+	if strings.Contains(message, "cannot have new lines") || strings.Contains(message, "unterminated") {
+		return "looks like a string is missing it's closing quote"
+	}
+
+	return ""
+}
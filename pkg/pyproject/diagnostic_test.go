@@ -0,0 +1,105 @@
+package pyproject
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParse_Diagnostics(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		error   string
+		snippet string
+		hint    string
+	}{
+		{
+			name:    "unquoted version",
+			content: "[project]\nname = \"demo\"\nversion = 1.2.3\n",
+			error:   "pyproject.toml:3:14: float can have at most one decimal point",
+			snippet: "1 | [project]\n2 | name = \"demo\"\n3 | version = 1.2.3\n  |              ^\n",
+			hint:    `versions are strings in TOML so must be quoted e.g. version = "1.2.3"`,
+		},
+		{
+			name:    "unquoted specifier",
+			content: "[project]\nrequires-python = >=3.8\n",
+			error:   "pyproject.toml:2:19: incomplete number",
+			hint:    `versions are strings in TOML so must be quoted e.g. requires-python = ">=3.8"`,
+		},
+		{
+			name:    "unclosed table header",
+			content: "[project\nname = \"demo\"\n",
+			error:   "pyproject.toml:1:9: expected character ]",
+			hint:    "table headers need a closing bracket e.g. [project]",
+		},
+		{
+			name:    "duplicate table",
+			content: "[project]\nname = \"demo\"\n\n[project]\nversion = \"1\"\n",
+			error:   "pyproject.toml:4:1: table project already exists",
+			snippet: "2 | name = \"demo\"\n3 | \n4 | [project]\n  | ^\n",
+			hint:    "each table can only be declared once, merge the two [project] tables together",
+		},
+		{
+			name:    "duplicate key",
+			content: "[project]\nname = \"demo\"\n  name = \"other\"\n",
+			error:   "pyproject.toml:3:3: key name is already defined",
+			hint:    `each key can only appear once in a table, remove one of the "name" keys from [project]`,
+		},
+		{
+			name:    "duplicate dotted table",
+			content: "[tool.poetry]\nname = \"demo\"\n\n[tool.poetry]\nversion = \"1\"\n",
+			error:   "pyproject.toml:4:1: table tool.poetry already exists",
+			snippet: "2 | name = \"demo\"\n3 | \n4 | [tool.poetry]\n  | ^\n",
+			hint:    "each table can only be declared once, merge the two [tool.poetry] tables together",
+		},
+		{
+			name:    "duplicate key in a later table",
+			content: "[tool.poetry]\nname = \"demo\"\n\n[project]\nname = \"demo\"\nversion = \"1\"\nname = \"other\"\n",
+			error:   "pyproject.toml:7:1: key name is already defined",
+			snippet: "5 | name = \"demo\"\n6 | version = \"1\"\n7 | name = \"other\"\n  | ^\n",
+			hint:    `each key can only appear once in a table, remove one of the "name" keys from [project]`,
+		},
+		{
+			name:    "duplicate top level key",
+			content: "name = \"demo\"\nname = \"other\"\n",
+			error:   "pyproject.toml:2:1: key name is already defined",
+			hint:    `each key can only appear once in a table, remove one of the "name" keys`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.content))
+			if err == nil {
+				t.Fatal("expected an error for invalid toml")
+			}
+
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("expected a *ParseError, got %T", err)
+			}
+
+			if got := parseErr.Error(); got != tt.error {
+				t.Errorf("got error %q, wanted %q", got, tt.error)
+			}
+
+			if tt.snippet != "" && parseErr.Snippet != tt.snippet {
+				t.Errorf("got snippet:\n%s\nwanted:\n%s", parseErr.Snippet, tt.snippet)
+			}
+
+			if parseErr.Hint != tt.hint {
+				t.Errorf("got hint %q, wanted %q", parseErr.Hint, tt.hint)
+			}
+		})
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	lines := strings.Split("a = 1\n\tb = 1.2.3\n", "\n")
+	got := snippet(lines, 2, 10)
+	want := "1 | a = 1\n2 | \tb = 1.2.3\n  | \t        ^\n"
+	if got != want {
+		t.Errorf("got:\n%s\nwanted:\n%s", got, want)
+	}
+}
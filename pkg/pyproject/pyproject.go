@@ -0,0 +1,254 @@
+// Package pyproject is a typed model of a project's pyproject.toml
+//
+// The file is read and parsed once, then the same model is handed to every detector
+// and installer. It covers the standardised tables: [build-system] (PEP 518),
+// [project] (PEP 621) and [dependency-groups] (PEP 735), with raw access to
+// the tool specific [tool.*] tables
+package pyproject
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/spf13/afero"
+)
+
+// File is the name of the file this package parses
+const File = "pyproject.toml"
+
+// PyProject is a parsed pyproject.toml
+//
+// Methods are safe to call on a nil *PyProject, which is what Read returns when
+// the project has no pyproject.toml, and behave as if every table were empty
+type PyProject struct {
+	BuildSystem      *BuildSystem             `toml:"build-system"`      // [build-system], nil if not declared
+	Project          *Project                 `toml:"project"`           // [project], nil if not declared
+	DependencyGroups map[string][]interface{} `toml:"dependency-groups"` // [dependency-groups], use Group to resolve
+	Tool             map[string]interface{}   `toml:"tool"`              // The raw [tool.*] tables, use HasTool and DecodeTool
+}
+
+// BuildSystem is the PEP 518 [build-system] table
+type BuildSystem struct {
+	BuildBackend string   `toml:"build-backend"` // The PEP 517 backend object e.g. "flit_core.buildapi"
+	Requires     []string `toml:"requires"`      // Requirements needed to build the project
+	BackendPath  []string `toml:"backend-path"`  // Directories holding an in-tree backend
+}
+
+// Project is the PEP 621 [project] table
+type Project struct {
+	OptionalDependencies map[string][]string `toml:"optional-dependencies"` // The project's extras
+	Name                 string              `toml:"name"`
+	Version              string              `toml:"version"`
+	Description          string              `toml:"description"`
+	RequiresPython       string              `toml:"requires-python"` // Version specifier for supported pythons e.g. ">=3.8"
+	Dependencies         []string            `toml:"dependencies"`
+	Dynamic              []string            `toml:"dynamic"` // Fields the build backend fills in
+}
+
+// Parse parses the contents of a pyproject.toml, if the toml is invalid the
+// error is a *ParseError describing where the problem is
+func Parse(data []byte) (*PyProject, error) {
+	var py PyProject
+	if err := toml.Unmarshal(data, &py); err != nil {
+		return nil, newParseError(data, err)
+	}
+
+	return &py, nil
+}
+
+// Read reads and parses the pyproject.toml in the root of 'fs', returning
+// nil if there isn't one
+func Read(fs afero.Afero) (*PyProject, error) {
+	exists, err := fs.Exists(File)
+	if err != nil {
+		return nil, fmt.Errorf("could not check for %s: %w", File, err)
+	}
+	if !exists {
+		return nil, nil
+	}
+
+	data, err := fs.ReadFile(File)
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %w", File, err)
+	}
+
+	py, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	return py, nil
+}
+
+// Backend returns the declared build-backend, or an empty string if there isn't one
+func (p *PyProject) Backend() string {
+	if p == nil || p.BuildSystem == nil {
+		return ""
+	}
+
+	return p.BuildSystem.BuildBackend
+}
+
+// Requires returns [build-system].requires
+func (p *PyProject) Requires() []string {
+	if p == nil || p.BuildSystem == nil {
+		return nil
+	}
+
+	return p.BuildSystem.Requires
+}
+
+// RequiresPython returns [project].requires-python, or an empty string if not declared
+func (p *PyProject) RequiresPython() string {
+	if p == nil || p.Project == nil {
+		return ""
+	}
+
+	return p.Project.RequiresPython
+}
+
+// Extras returns the sorted names of the extras in [project.optional-dependencies]
+func (p *PyProject) Extras() []string {
+	if p == nil || p.Project == nil {
+		return nil
+	}
+
+	extras := make([]string, 0, len(p.Project.OptionalDependencies))
+	for extra := range p.Project.OptionalDependencies {
+		extras = append(extras, extra)
+	}
+	sort.Strings(extras)
+
+	return extras
+}
+
+// IsDynamic reports whether the [project] 'field' is filled in by the build backend
+func (p *PyProject) IsDynamic(field string) bool {
+	if p == nil || p.Project == nil {
+		return false
+	}
+
+	for _, dynamic := range p.Project.Dynamic {
+		if dynamic == field {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Groups returns the sorted names of the PEP 735 dependency groups
+func (p *PyProject) Groups() []string {
+	if p == nil {
+		return nil
+	}
+
+	groups := make([]string, 0, len(p.DependencyGroups))
+	for group := range p.DependencyGroups {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+
+	return groups
+}
+
+// Group returns the requirements in the PEP 735 dependency group 'name', with
+// any {include-group = "..."} entries expanded
+func (p *PyProject) Group(name string) ([]string, error) {
+	return p.group(name, nil)
+}
+
+// group resolves the dependency group 'name', 'parents' are the groups currently
+// being resolved so include cycles can be reported rather than recursing forever
+func (p *PyProject) group(name string, parents []string) ([]string, error) {
+	for _, parent := range parents {
+		if parent == name {
+			return nil, fmt.Errorf("dependency group %q includes itself: %s", name, strings.Join(append(parents, name), " -> "))
+		}
+	}
+
+	var entries []interface{}
+	var ok bool
+	if p != nil {
+		entries, ok = p.DependencyGroups[name]
+	}
+	if !ok {
+		return nil, fmt.Errorf("no dependency group named %q", name)
+	}
+
+	var reqs []string
+	for _, entry := range entries {
+		switch entry := entry.(type) {
+		case string:
+			reqs = append(reqs, entry)
+
+		case map[string]interface{}:
+			include, ok := entry["include-group"].(string)
+			if !ok {
+				return nil, fmt.Errorf("dependency group %q: unrecognised entry %v", name, entry)
+			}
+			included, err := p.group(include, append(parents, name))
+			if err != nil {
+				return nil, fmt.Errorf("%w", err)
+			}
+			reqs = append(reqs, included...)
+
+		default:
+			return nil, fmt.Errorf("dependency group %q: unrecognised entry %v", name, entry)
+		}
+	}
+
+	return reqs, nil
+}
+
+// HasTool reports whether there is a table at the dotted 'path' under [tool]
+// e.g. HasTool("poetry") for [tool.poetry] or HasTool("hatch.envs.default")
+func (p *PyProject) HasTool(path string) bool {
+	_, ok := p.toolTable(path)
+	return ok
+}
+
+// DecodeTool decodes the table at the dotted 'path' under [tool] into 'v' which
+// should be a pointer to a struct with toml tags, if the table doesn't exist 'v'
+// is left untouched
+//
+// This lets each tool's package describe only the part of [tool] it cares about
+func (p *PyProject) DecodeTool(path string, v interface{}) error {
+	table, ok := p.toolTable(path)
+	if !ok {
+		return nil
+	}
+
+	// The simplest faithful way to turn the generic table into 'v' is to
+	// round trip it through toml
+	data, err := toml.Marshal(table)
+	if err != nil {
+		return fmt.Errorf("could not encode [tool.%s]: %w", path, err)
+	}
+
+	if err := toml.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("could not decode [tool.%s]: %w", path, err)
+	}
+
+	return nil
+}
+
+// toolTable returns the table at the dotted 'path' under [tool]
+func (p *PyProject) toolTable(path string) (map[string]interface{}, bool) {
+	if p == nil {
+		return nil, false
+	}
+
+	current := p.Tool
+	for _, key := range strings.Split(path, ".") {
+		next, ok := current[key].(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		current = next
+	}
+
+	return current, true
+}
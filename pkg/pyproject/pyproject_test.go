@@ -0,0 +1,166 @@
+package pyproject
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+const content = `[build-system]
+requires = ["hatchling"]
+build-backend = "hatchling.build"
+
+[project]
+name = "demo"
+requires-python = ">=3.9"
+dependencies = ["requests"]
+dynamic = ["version"]
+
+[project.optional-dependencies]
+test = ["pytest"]
+docs = ["mkdocs"]
+
+[dependency-groups]
+lint = ["ruff"]
+test = ["pytest", {include-group = "lint"}]
+dev = [{include-group = "test"}, "ipython"]
+loop = [{include-group = "loop"}]
+
+[tool.hatch.envs.default]
+dependencies = ["coverage"]
+features = ["test"]
+
+[tool.black]
+line-length = 100
+`
+
+func TestRead(t *testing.T) {
+	t.Run("no pyproject", func(t *testing.T) {
+		py, err := Read(afero.Afero{Fs: afero.NewMemMapFs()})
+		if err != nil {
+			t.Fatalf("Read returned an error: %v", err)
+		}
+		if py != nil {
+			t.Errorf("expected nil, got %#v", py)
+		}
+
+		// Methods should all be safe on nil
+		if py.Backend() != "" || py.RequiresPython() != "" || py.Extras() != nil || py.HasTool("poetry") {
+			t.Error("nil PyProject should behave as if empty")
+		}
+	})
+
+	t.Run("full", func(t *testing.T) {
+		af := afero.Afero{Fs: afero.NewMemMapFs()}
+		if err := af.WriteFile(File, []byte(content), 0o755); err != nil {
+			t.Fatalf("could not create file: %v", err)
+		}
+
+		py, err := Read(af)
+		if err != nil {
+			t.Fatalf("Read returned an error: %v", err)
+		}
+
+		if got := py.Backend(); got != "hatchling.build" {
+			t.Errorf("got backend %q, wanted hatchling.build", got)
+		}
+		if got := py.Requires(); !reflect.DeepEqual(got, []string{"hatchling"}) {
+			t.Errorf("got requires %v", got)
+		}
+		if got := py.RequiresPython(); got != ">=3.9" {
+			t.Errorf("got requires-python %q", got)
+		}
+		if got := py.Extras(); !reflect.DeepEqual(got, []string{"docs", "test"}) {
+			t.Errorf("got extras %v", got)
+		}
+		if !py.IsDynamic("version") || py.IsDynamic("name") {
+			t.Errorf("wrong dynamic fields: %v", py.Project.Dynamic)
+		}
+		if got := py.Groups(); !reflect.DeepEqual(got, []string{"dev", "lint", "loop", "test"}) {
+			t.Errorf("got groups %v", got)
+		}
+		if !py.HasTool("black") || !py.HasTool("hatch.envs.default") || py.HasTool("poetry") {
+			t.Error("wrong tool tables")
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		af := afero.Afero{Fs: afero.NewMemMapFs()}
+		if err := af.WriteFile(File, []byte("[project\nname = 1"), 0o755); err != nil {
+			t.Fatalf("could not create file: %v", err)
+		}
+
+		if _, err := Read(af); err == nil {
+			t.Error("expected an error for invalid toml")
+		}
+	})
+}
+
+func TestPyProject_Group(t *testing.T) {
+	py, err := Parse([]byte(content))
+	if err != nil {
+		t.Fatalf("Parse returned an error: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		errMsg  string
+		want    []string
+		wantErr bool
+	}{
+		{name: "lint", want: []string{"ruff"}},
+		{name: "test", want: []string{"pytest", "ruff"}},
+		{name: "dev", want: []string{"pytest", "ruff", "ipython"}},
+		{name: "loop", wantErr: true, errMsg: "includes itself"},
+		{name: "missing", wantErr: true, errMsg: "no dependency group"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := py.Group(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Group() err = %v, wantErr = %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if !strings.Contains(err.Error(), tt.errMsg) {
+					t.Errorf("got error %q, wanted it to contain %q", err, tt.errMsg)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, wanted %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPyProject_DecodeTool(t *testing.T) {
+	py, err := Parse([]byte(content))
+	if err != nil {
+		t.Fatalf("Parse returned an error: %v", err)
+	}
+
+	var env struct {
+		Dependencies []string `toml:"dependencies"`
+		Features     []string `toml:"features"`
+	}
+	if err := py.DecodeTool("hatch.envs.default", &env); err != nil {
+		t.Fatalf("DecodeTool returned an error: %v", err)
+	}
+
+	if !reflect.DeepEqual(env.Dependencies, []string{"coverage"}) || !reflect.DeepEqual(env.Features, []string{"test"}) {
+		t.Errorf("wrong env decoded: %#v", env)
+	}
+
+	var missing struct {
+		Name string `toml:"name"`
+	}
+	if err := py.DecodeTool("poetry", &missing); err != nil {
+		t.Fatalf("DecodeTool returned an error for a missing table: %v", err)
+	}
+	if missing.Name != "" {
+		t.Errorf("missing table should leave value untouched, got %#v", missing)
+	}
+}
@@ -0,0 +1,128 @@
+package python
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+)
+
+// Backend names accepted by SelectBackend
+const (
+	BackendAuto = "auto"
+	BackendPip  = "pip"
+	BackendUV   = "uv"
+)
+
+// Backend is the tooling used to create virtual environments and install
+// packages into them
+type Backend interface {
+	// Name returns the name of the backend e.g. "pip"
+	Name() string
+
+	// CreateVenv creates a virtual environment named ".venv" in cwd
+	CreateVenv(cwd string, stdout, stderr io.Writer) error
+
+	// UpdateSeeds updates pip, setuptools and wheel in the virtual environment
+	UpdateSeeds(cwd string, stdout, stderr io.Writer) error
+
+	// InstallRequirements installs the requirements file 'file' into the virtual environment
+	InstallRequirements(cwd string, stdout, stderr io.Writer, file string) error
+
+	// Install installs into the virtual environment, installArgs are
+	// the same arguments that would be passed to "pip install"
+	Install(cwd string, stdout, stderr io.Writer, installArgs []string) error
+}
+
+// SelectBackend returns the Backend given by 'name', an empty name or "auto" selects
+// uv if it is installed, falling back to pip otherwise
+func SelectBackend(name string) (Backend, error) {
+	switch name {
+	case "", BackendAuto:
+		if _, err := lookPath("uv"); err == nil {
+			return UV{}, nil
+		}
+		return Pip{}, nil
+	case BackendPip:
+		return Pip{}, nil
+	case BackendUV:
+		if _, err := lookPath("uv"); err != nil {
+			return nil, fmt.Errorf("uv backend selected but uv is not installed: %w", err)
+		}
+		return UV{}, nil
+	default:
+		return nil, fmt.Errorf("unrecognised backend %q, must be one of %q, %q or %q", name, BackendAuto, BackendPip, BackendUV)
+	}
+}
+
+// venvPython is the path to the virtual environment's python, relative to cwd
+const venvPython = ".venv/bin/python"
+
+// newUVCmd returns an exec.Cmd configured with the parameters passed in
+// pointing to whatever uv is on $PATH
+func newUVCmd(cwd string, stdout, stderr io.Writer, args []string) *exec.Cmd {
+	cmd := uvCommand("uv", args...)
+	cmd.Dir = cwd
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	return cmd
+}
+
+// UV is the Backend using uv (https://github.com/astral-sh/uv) which is much
+// faster than pip, especially on large sets of requirements
+type UV struct{}
+
+// Name implements Backend
+func (UV) Name() string {
+	return "uv"
+}
+
+// CreateVenv implements Backend by calling "uv venv --seed .venv", the seed
+// packages are installed so that tools expecting pip in the environment still work
+func (UV) CreateVenv(cwd string, stdout, stderr io.Writer) error {
+	cmd := newUVCmd(cwd, stdout, stderr, []string{"venv", "--seed", ".venv"})
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("could not create virtual environment: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateSeeds implements Backend by calling "uv pip install --upgrade pip setuptools wheel"
+func (u UV) UpdateSeeds(cwd string, stdout, stderr io.Writer) error {
+	if err := u.pipInstall(cwd, stdout, stderr, []string{"--upgrade", "pip", "setuptools", "wheel"}); err != nil {
+		return fmt.Errorf("could not update seeds: %w", err)
+	}
+
+	return nil
+}
+
+// InstallRequirements implements Backend by calling "uv pip install -r 'file'"
+func (u UV) InstallRequirements(cwd string, stdout, stderr io.Writer, file string) error {
+	if err := u.pipInstall(cwd, stdout, stderr, []string{"-r", file}); err != nil {
+		return fmt.Errorf("could not install requirements from %s: %w", file, err)
+	}
+
+	return nil
+}
+
+// Install implements Backend, installArgs are passed to "uv pip install ..."
+func (u UV) Install(cwd string, stdout, stderr io.Writer, installArgs []string) error {
+	if err := u.pipInstall(cwd, stdout, stderr, installArgs); err != nil {
+		return fmt.Errorf("could not install %v: %w", installArgs, err)
+	}
+
+	return nil
+}
+
+// pipInstall calls "uv pip install" targeting the virtual environment in cwd
+func (UV) pipInstall(cwd string, stdout, stderr io.Writer, installArgs []string) error {
+	args := []string{"pip", "install", "--python", venvPython}
+	args = append(args, installArgs...)
+	cmd := newUVCmd(cwd, stdout, stderr, args)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	return nil
+}
@@ -1,6 +1,6 @@
 // Package python provides functions that wrap external commands to create
 // standard python virtual environments and install dependencies using the
-// built in python tooling (e.g. pip, venv, setuptools)
+// built in python tooling (e.g. pip, venv, setuptools) or uv
 package python
 
 import (
@@ -10,12 +10,27 @@ import (
 	"path/filepath"
 )
 
-// pythonCommand is an internal reassignment of exec.Command
+// pythonCommand and uvCommand are internal reassignments of exec.Command
 // used for mocking during tests
 var (
 	pythonCommand = exec.Command
+	uvCommand     = exec.Command
+	lookPath      = exec.LookPath
 )
 
+// backend is the Backend used by the package level functions
+var backend Backend = Pip{}
+
+// Use sets the Backend used by CreateVenv, UpdateSeeds, InstallRequirements and Install
+func Use(b Backend) {
+	backend = b
+}
+
+// Current returns the Backend currently in use
+func Current() Backend {
+	return backend
+}
+
 // newPythonCmd returns an exec.Cmd configured with the parameters passed in
 // pointing to whatever python is on $PATH
 func newPythonCmd(cwd string, stdout, stderr io.Writer, args []string) *exec.Cmd {
@@ -30,7 +45,7 @@ func newPythonCmd(cwd string, stdout, stderr io.Writer, args []string) *exec.Cmd
 // newVenvCmd returns an exec.Cmd configured with the parameters passed in
 // pointing to the virtual environment's python
 func newVenvCmd(cwd string, stdout, stderr io.Writer, args []string) *exec.Cmd {
-	python := filepath.Join(cwd, ".venv/bin/python")
+	python := filepath.Join(cwd, venvPython)
 	cmd := pythonCommand(python, args...)
 	cmd.Dir = cwd
 	cmd.Stdout = stdout
@@ -44,6 +59,52 @@ func newVenvCmd(cwd string, stdout, stderr io.Writer, args []string) *exec.Cmd {
 // The wrapped external command will be hooked up directly to stdout and stderr and
 // will wait for the command to complete before returning
 func CreateVenv(cwd string, stdout, stderr io.Writer) error {
+	if err := backend.CreateVenv(cwd, stdout, stderr); err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	return nil
+}
+
+// UpdateSeeds will update pip, setuptools and wheel in the virtual environment
+func UpdateSeeds(cwd string, stdout, stderr io.Writer) error {
+	if err := backend.UpdateSeeds(cwd, stdout, stderr); err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	return nil
+}
+
+// InstallRequirements will install into a virtual environment the dependencies
+// specified in a requirements file given by `file`
+func InstallRequirements(cwd string, stdout, stderr io.Writer, file string) error {
+	if err := backend.InstallRequirements(cwd, stdout, stderr, file); err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	return nil
+}
+
+// Install is a wrapper around installing into the virtual environment
+// installArgs are effectively passed to "python -m pip install ..."
+func Install(cwd string, stdout, stderr io.Writer, installArgs []string) error {
+	if err := backend.Install(cwd, stdout, stderr, installArgs); err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	return nil
+}
+
+// Pip is the Backend using python's built in venv module and pip
+type Pip struct{}
+
+// Name implements Backend
+func (Pip) Name() string {
+	return "pip"
+}
+
+// CreateVenv implements Backend by calling "python -m venv .venv"
+func (Pip) CreateVenv(cwd string, stdout, stderr io.Writer) error {
 	cmd := newPythonCmd(cwd, stdout, stderr, []string{"-m", "venv", ".venv"})
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("could not create virtual environment: %w", err)
@@ -52,8 +113,9 @@ func CreateVenv(cwd string, stdout, stderr io.Writer) error {
 	return nil
 }
 
-// UpdateSeeds will use the virtual environment's python to update pip, setuptools and wheel
-func UpdateSeeds(cwd string, stdout, stderr io.Writer) error {
+// UpdateSeeds implements Backend by using the virtual environment's python to
+// update pip, setuptools and wheel
+func (Pip) UpdateSeeds(cwd string, stdout, stderr io.Writer) error {
 	cmd := newVenvCmd(cwd, stdout, stderr, []string{"-m", "pip", "install", "--upgrade", "pip", "setuptools", "wheel"})
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("could not update seeds: %w", err)
@@ -62,20 +124,18 @@ func UpdateSeeds(cwd string, stdout, stderr io.Writer) error {
 	return nil
 }
 
-// InstallRequirements will call pip to install into a virtual environment the dependencies
-// specified in a requirements file given by `file`
-func InstallRequirements(cwd string, stdout, stderr io.Writer, file string) error {
+// InstallRequirements implements Backend by calling pip install -r 'file'
+func (Pip) InstallRequirements(cwd string, stdout, stderr io.Writer, file string) error {
 	cmd := newVenvCmd(cwd, stdout, stderr, []string{"-m", "pip", "install", "-r", file})
 	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("could not update seeds: %w", err)
+		return fmt.Errorf("could not install requirements from %s: %w", file, err)
 	}
 
 	return nil
 }
 
-// Install is a wrapper around the virtual environment's pip install
-// installArgs are effectively passed to "python -m pip install ..."
-func Install(cwd string, stdout, stderr io.Writer, installArgs []string) error {
+// Install implements Backend, installArgs are passed to "python -m pip install ..."
+func (Pip) Install(cwd string, stdout, stderr io.Writer, installArgs []string) error {
 	args := []string{"-m", "pip", "install"}
 	args = append(args, installArgs...)
 	cmd := newVenvCmd(cwd, stdout, stderr, args)
@@ -85,3 +145,16 @@ func Install(cwd string, stdout, stderr io.Writer, installArgs []string) error {
 
 	return nil
 }
+
+// RunModule runs a python module with the virtual environment's python
+// i.e. ".venv/bin/python -m 'module' args..." regardless of the backend in use
+func RunModule(cwd string, stdout, stderr io.Writer, module string, args []string) error {
+	cmdArgs := []string{"-m", module}
+	cmdArgs = append(cmdArgs, args...)
+	cmd := newVenvCmd(cwd, stdout, stderr, cmdArgs)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("could not run %s: %w", module, err)
+	}
+
+	return nil
+}
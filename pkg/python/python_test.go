@@ -1,6 +1,7 @@
 package python
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -53,13 +54,27 @@ func fakeExecCommand(command string, args ...string) *exec.Cmd {
 	return cmd
 }
 
+// fakeLookPath returns a lookPath that either finds every executable or none
+func fakeLookPath(found bool) func(string) (string, error) {
+	return func(file string) (string, error) {
+		if found {
+			return "/usr/bin/" + file, nil
+		}
+		return "", errors.New("not found")
+	}
+}
+
 func setUp(testcase string) {
 	pythonCommand = fakeExecCommand
+	uvCommand = fakeExecCommand
 	testCase = testcase
 }
 
 func tearDown() {
 	pythonCommand = exec.Command
+	uvCommand = exec.Command
+	lookPath = exec.LookPath
+	Use(Pip{})
 }
 
 // This is the main helper process for external command tests. It first checks whether or not go test wants to use it
@@ -91,6 +106,32 @@ func TestPythonHelperProcess(t *testing.T) {
 		// Simulate failure by printing to stderr and exit 1
 		fmt.Fprintf(os.Stderr, "something wrong")
 		os.Exit(1)
+
+	case "run_module_success":
+		expectedArgs := []string{".venv/bin/python", "-m", "piptools", "sync", "requirements.txt"}
+		assertCorrectArgs(expectedArgs, args)
+
+	case "run_module_error":
+		// Simulate failure by printing to stderr and exit 1
+		fmt.Fprintf(os.Stderr, "something wrong")
+		os.Exit(1)
+
+	case "uv_create_venv_success":
+		expectedArgs := []string{"uv", "venv", "--seed", ".venv"}
+		assertCorrectArgs(expectedArgs, args)
+
+	case "uv_install_requirements_success":
+		expectedArgs := []string{"uv", "pip", "install", "--python", ".venv/bin/python", "-r", "requirements.txt"}
+		assertCorrectArgs(expectedArgs, args)
+
+	case "uv_install_success":
+		expectedArgs := []string{"uv", "pip", "install", "--python", ".venv/bin/python", "-e", ".[dev]"}
+		assertCorrectArgs(expectedArgs, args)
+
+	case "uv_install_error":
+		// Simulate failure by printing to stderr and exit 1
+		fmt.Fprintf(os.Stderr, "something wrong")
+		os.Exit(1)
 	}
 }
 
@@ -120,3 +161,139 @@ func TestCreateVenv(t *testing.T) {
 		})
 	}
 }
+
+func TestRunModule(t *testing.T) {
+	tests := []struct {
+		testcase string
+		wantErr  bool
+	}{
+		{
+			testcase: "run_module_success",
+			wantErr:  false,
+		},
+		{
+			testcase: "run_module_error",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.testcase, func(t *testing.T) {
+			setUp(tt.testcase)
+			defer tearDown()
+
+			if err := RunModule(".", os.Stdout, os.Stderr, "piptools", []string{"sync", "requirements.txt"}); (err != nil) != tt.wantErr {
+				t.Errorf("RunModule() error = %v, wantErr = %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUV(t *testing.T) {
+	t.Run("create venv", func(t *testing.T) {
+		setUp("uv_create_venv_success")
+		defer tearDown()
+		Use(UV{})
+
+		if err := CreateVenv(".", os.Stdout, os.Stderr); err != nil {
+			t.Errorf("CreateVenv() returned an error: %v", err)
+		}
+	})
+
+	t.Run("install requirements", func(t *testing.T) {
+		setUp("uv_install_requirements_success")
+		defer tearDown()
+		Use(UV{})
+
+		if err := InstallRequirements(".", os.Stdout, os.Stderr, "requirements.txt"); err != nil {
+			t.Errorf("InstallRequirements() returned an error: %v", err)
+		}
+	})
+
+	t.Run("install", func(t *testing.T) {
+		setUp("uv_install_success")
+		defer tearDown()
+		Use(UV{})
+
+		if err := Install(".", os.Stdout, os.Stderr, []string{"-e", ".[dev]"}); err != nil {
+			t.Errorf("Install() returned an error: %v", err)
+		}
+	})
+
+	t.Run("install error", func(t *testing.T) {
+		setUp("uv_install_error")
+		defer tearDown()
+		Use(UV{})
+
+		if err := Install(".", os.Stdout, os.Stderr, []string{"-e", "."}); err == nil {
+			t.Error("Install() did not return an error")
+		}
+	})
+}
+
+func TestSelectBackend(t *testing.T) {
+	tests := []struct {
+		name    string
+		backend string
+		want    string
+		hasUV   bool
+		wantErr bool
+	}{
+		{
+			name:    "auto with uv",
+			backend: "",
+			hasUV:   true,
+			want:    "uv",
+			wantErr: false,
+		},
+		{
+			name:    "auto without uv",
+			backend: "auto",
+			hasUV:   false,
+			want:    "pip",
+			wantErr: false,
+		},
+		{
+			name:    "pip even with uv",
+			backend: "pip",
+			hasUV:   true,
+			want:    "pip",
+			wantErr: false,
+		},
+		{
+			name:    "uv explicitly",
+			backend: "uv",
+			hasUV:   true,
+			want:    "uv",
+			wantErr: false,
+		},
+		{
+			name:    "uv explicitly but not installed",
+			backend: "uv",
+			hasUV:   false,
+			wantErr: true,
+		},
+		{
+			name:    "unknown",
+			backend: "conda",
+			hasUV:   true,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lookPath = fakeLookPath(tt.hasUV)
+			defer tearDown()
+
+			got, err := SelectBackend(tt.backend)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SelectBackend() error = %v, wantErr = %v", err, tt.wantErr)
+			}
+
+			if err == nil && got.Name() != tt.want {
+				t.Errorf("got backend %q, wanted %q", got.Name(), tt.want)
+			}
+		})
+	}
+}
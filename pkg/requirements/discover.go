@@ -0,0 +1,127 @@
+package requirements
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// defaultPatterns are the glob patterns matching the common requirements file layouts
+var defaultPatterns = []string{
+	"requirements*.txt",  // requirements.txt, requirements-dev.txt, requirements_test.txt
+	"*-requirements.txt", // dev-requirements.txt
+	"*_requirements.txt", // dev_requirements.txt
+	"requirements/*.txt", // requirements/base.txt, requirements/dev.txt
+}
+
+// qualifierRanks is how strongly a requirements file qualifier (the "dev" in
+// requirements-dev.txt or requirements/dev.txt) suggests the file contains
+// everything needed to work on the project, lower is better
+//
+// Only development files outrank the base requirements, the likes of docs or
+// lint files usually hold just the tools for that job and not the project's own dependencies
+var qualifierRanks = map[string]int{
+	"dev":         0,
+	"develop":     0,
+	"development": 0,
+	"":            baseRank, // plain requirements.txt
+	"base":        baseRank,
+	"common":      baseRank,
+	"main":        baseRank,
+	"local":       2,
+	"test":        3,
+	"tests":       3,
+	"testing":     3,
+	"lint":        4,
+	"typing":      4,
+	"docs":        5,
+	"doc":         5,
+}
+
+// baseRank is the rank of the files holding the project's base requirements
+const baseRank = 1
+
+// unknownRank is the rank of a file with a qualifier we don't recognise
+// e.g. requirements-prod.txt
+const unknownRank = 10
+
+// Discover finds every requirements file matching the common layouts plus any
+// extra user supplied glob 'patterns', ranked so that the files most likely to
+// contain everything needed for development come first
+func Discover(fs afero.Afero, patterns []string) ([]string, error) {
+	return glob(fs, append(append([]string{}, defaultPatterns...), patterns...))
+}
+
+// Rank returns the rank of the requirements file at 'path', lower ranks are
+// preferred when choosing which file to install
+func Rank(path string) int {
+	rank, ok := qualifierRanks[qualifier(path)]
+	if !ok {
+		return unknownRank
+	}
+
+	return rank
+}
+
+// glob returns every file matched by any of 'patterns', deduplicated and ranked
+func glob(fs afero.Afero, patterns []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var files []string
+
+	for _, pattern := range patterns {
+		matches, err := afero.Glob(fs, pattern)
+		if err != nil {
+			return nil, fmt.Errorf("bad requirements pattern %q: %w", pattern, err)
+		}
+
+		for _, match := range matches {
+			match = filepath.ToSlash(match)
+			if seen[match] {
+				continue
+			}
+			isDir, err := fs.IsDir(match)
+			if err != nil {
+				return nil, fmt.Errorf("could not stat %s: %w", match, err)
+			}
+			if isDir {
+				continue
+			}
+			seen[match] = true
+			files = append(files, match)
+		}
+	}
+
+	sort.SliceStable(files, func(i, j int) bool {
+		ri, rj := Rank(files[i]), Rank(files[j])
+		if ri != rj {
+			return ri < rj
+		}
+		// Prefer files at the top level, then alphabetical for stability
+		di, dj := strings.Count(files[i], "/"), strings.Count(files[j], "/")
+		if di != dj {
+			return di < dj
+		}
+		return files[i] < files[j]
+	})
+
+	return files, nil
+}
+
+// qualifier extracts the part of a requirements file name that says what it's for
+// e.g. "dev" for requirements-dev.txt, dev-requirements.txt and requirements/dev.txt
+func qualifier(path string) string {
+	name := strings.ToLower(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)))
+
+	for _, sep := range []string{"-", "_", "."} {
+		name = strings.TrimPrefix(name, "requirements"+sep)
+		name = strings.TrimSuffix(name, sep+"requirements")
+	}
+	if name == "requirements" {
+		return ""
+	}
+
+	return name
+}
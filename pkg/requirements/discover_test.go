@@ -0,0 +1,125 @@
+package requirements
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestRank(t *testing.T) {
+	tests := []struct {
+		path string
+		want int
+	}{
+		{path: "requirements.txt", want: baseRank},
+		{path: "requirements-dev.txt", want: 0},
+		{path: "requirements_dev.txt", want: 0},
+		{path: "dev-requirements.txt", want: 0},
+		{path: "requirements-test.txt", want: 3},
+		{path: "requirements-docs.txt", want: 5},
+		{path: "requirements/dev.txt", want: 0},
+		{path: "requirements/base.txt", want: baseRank},
+		{path: "requirements/prod.txt", want: unknownRank},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := Rank(tt.path); got != tt.want {
+				t.Errorf("got %d, wanted %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    []string
+		patterns []string
+		want     []string
+	}{
+		{
+			name:  "nothing",
+			files: []string{"pyproject.toml"},
+			want:  nil,
+		},
+		{
+			name:  "classic",
+			files: []string{"requirements.txt", "requirements-dev.txt"},
+			want:  []string{"requirements-dev.txt", "requirements.txt"},
+		},
+		{
+			name:  "requirements directory",
+			files: []string{"requirements/base.txt", "requirements/prod.txt", "requirements/test.txt"},
+			want:  []string{"requirements/base.txt", "requirements/test.txt", "requirements/prod.txt"},
+		},
+		{
+			name:  "top level preferred over directory",
+			files: []string{"requirements/dev.txt", "dev-requirements.txt"},
+			want:  []string{"dev-requirements.txt", "requirements/dev.txt"},
+		},
+		{
+			name:     "user patterns",
+			files:    []string{"requirements.txt", "deps/ci.txt"},
+			patterns: []string{"deps/*.txt"},
+			want:     []string{"requirements.txt", "deps/ci.txt"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			af := afero.Afero{Fs: afero.NewMemMapFs()}
+			for _, file := range tt.files {
+				if err := af.WriteFile(file, []byte(""), 0o755); err != nil {
+					t.Fatalf("could not create file: %v", err)
+				}
+			}
+
+			got, err := Discover(af, tt.patterns)
+			if err != nil {
+				t.Fatalf("Discover returned an error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, wanted %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIncludes(t *testing.T) {
+	af := afero.Afero{Fs: afero.NewMemMapFs()}
+	files := map[string]string{
+		"requirements/dev.txt":  "-r base.txt\n--constraint=constraints.txt\n-r https://example.com/reqs.txt\npytest\n",
+		"requirements/base.txt": "-rrequirements/dev.txt\nrequests\n",
+		"constraints.txt":       "",
+	}
+	for file, content := range files {
+		if err := af.WriteFile(file, []byte(content), 0o755); err != nil {
+			t.Fatalf("could not create file: %v", err)
+		}
+	}
+
+	got, err := Includes(af, "requirements/dev.txt")
+	if err != nil {
+		t.Fatalf("Includes returned an error: %v", err)
+	}
+
+	// requirements/constraints.txt doesn't exist so is skipped, as is the remote include
+	want := []string{"requirements/dev.txt", "requirements/base.txt"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, wanted %v", got, want)
+	}
+
+	// Direct references are returned whether they exist or not
+	refs, err := Referenced(af, "requirements/dev.txt")
+	if err != nil {
+		t.Fatalf("Referenced returned an error: %v", err)
+	}
+
+	want = []string{"requirements/base.txt", "requirements/constraints.txt"}
+	if !reflect.DeepEqual(refs, want) {
+		t.Errorf("got %v, wanted %v", refs, want)
+	}
+}
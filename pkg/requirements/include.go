@@ -0,0 +1,130 @@
+package requirements
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// includeFlags are the requirements file options that pull in another file
+var includeFlags = []string{"-r", "--requirement", "-c", "--constraint"}
+
+// Includes returns the requirements file 'file' along with every file it pulls
+// in with -r or -c, directly or not, in the order they are found
+//
+// Files that don't exist are skipped, pip will complain about them far more
+// helpfully than we can
+func Includes(fsys afero.Afero, file string) ([]string, error) {
+	var files []string
+	if err := includes(fsys, file, make(map[string]bool), &files); err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	return files, nil
+}
+
+// includes appends the requirements file 'file' and every file it includes
+// to 'files', skipping any already 'seen'
+func includes(fsys afero.Afero, file string, seen map[string]bool, files *[]string) error {
+	file = path.Clean(file)
+	if seen[file] {
+		return nil
+	}
+
+	data, err := fsys.ReadFile(file)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("could not read %s: %w", file, err)
+	}
+	seen[file] = true
+	*files = append(*files, file)
+
+	refs, err := references(file, data)
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+	for _, ref := range refs {
+		if err := includes(fsys, ref, seen, files); err != nil {
+			return fmt.Errorf("%w", err)
+		}
+	}
+
+	return nil
+}
+
+// Referenced returns the files the requirements file 'file' pulls in directly
+// with -r or -c, whether or not they exist (yet)
+func Referenced(fsys afero.Afero, file string) ([]string, error) {
+	file = path.Clean(file)
+	data, err := fsys.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %w", file, err)
+	}
+
+	refs, err := references(file, data)
+	if err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	return refs, nil
+}
+
+// references returns the files included by the requirements file 'file' with
+// contents 'data', relative to the project root
+func references(file string, data []byte) ([]string, error) {
+	var refs []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		include := included(scanner.Text())
+		if include == "" {
+			continue
+		}
+		// Includes are relative to the file that includes them
+		refs = append(refs, path.Join(path.Dir(file), include))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read %s: %w", file, err)
+	}
+
+	return refs, nil
+}
+
+// included returns the file included by a requirements file 'line', or an
+// empty string if it doesn't include one
+func included(line string) string {
+	line = strings.TrimSpace(line)
+	for _, flag := range includeFlags {
+		if !strings.HasPrefix(line, flag) {
+			continue
+		}
+
+		rest := line[len(flag):]
+		switch {
+		case strings.HasPrefix(rest, "="):
+			rest = rest[1:]
+		case strings.HasPrefix(rest, " "), strings.HasPrefix(rest, "\t"):
+		default:
+			// e.g. "-rrequirements.txt" is valid for short flags, but "--requirementx" isn't a flag at all
+			if strings.HasPrefix(flag, "--") {
+				continue
+			}
+		}
+
+		fields := strings.Fields(rest)
+		if len(fields) == 0 || strings.Contains(fields[0], "://") {
+			// Remote includes aren't files in the project
+			return ""
+		}
+		return fields[0]
+	}
+
+	return ""
+}
@@ -0,0 +1,123 @@
+// Package requirements implements detection of projects whose dependencies
+// are declared in pip requirements files
+package requirements
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/FollowTheProcess/venv/pkg/project"
+	"github.com/FollowTheProcess/venv/pkg/pyproject"
+	"github.com/FollowTheProcess/venv/pkg/python"
+	"github.com/spf13/afero"
+)
+
+// Detector recognises projects with requirements files, either in the project root
+// (requirements.txt, requirements-dev.txt, dev-requirements.txt etc.) or under a
+// requirements/ directory
+//
+// Files aimed at development are preferred over plain requirements.txt as in projects
+// where they exist, they typically contain everything needed to work on it. If the
+// chosen file doesn't pull in the base requirements with -r, they are installed too
+type Detector struct {
+	Patterns []string // Extra user supplied glob patterns, if set every file they match is installed
+}
+
+// Name implements project.Detector
+func (Detector) Name() string {
+	return "requirements"
+}
+
+// Detect implements project.Detector
+func (d Detector) Detect(fs afero.Afero, _ *pyproject.PyProject) (project.Match, error) {
+	files, err := Discover(fs, d.Patterns)
+	if err != nil {
+		return project.Match{}, fmt.Errorf("%w", err)
+	}
+	if len(files) == 0 {
+		return project.Match{}, nil
+	}
+
+	// By default only install the best file (and the base requirements if it doesn't
+	// already pull them in), user patterns install everything they match
+	var chosen []string
+	if len(d.Patterns) != 0 {
+		chosen, err = glob(fs, d.Patterns)
+		if err != nil {
+			return project.Match{}, fmt.Errorf("%w", err)
+		}
+	}
+	if len(chosen) == 0 {
+		chosen, err = best(fs, files)
+		if err != nil {
+			return project.Match{}, fmt.Errorf("%w", err)
+		}
+	}
+
+	return project.Match{
+		Confidence: project.High,
+		Reasons:    []string{fmt.Sprintf("found %s", strings.Join(files, ", ")), fmt.Sprintf("chose %s", strings.Join(chosen, ", "))},
+		Plan:       Plan(chosen...),
+	}, nil
+}
+
+// best returns the best of the ranked requirements 'files' to install, preceded
+// by the base requirements if the best file doesn't include them itself
+func best(fs afero.Afero, files []string) ([]string, error) {
+	chosen := files[0]
+	if Rank(chosen) == baseRank {
+		return []string{chosen}, nil
+	}
+
+	var base string
+	for _, file := range files {
+		if Rank(file) == baseRank {
+			base = file
+			break
+		}
+	}
+	if base == "" {
+		return []string{chosen}, nil
+	}
+
+	included, err := Includes(fs, chosen)
+	if err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+	for _, include := range included {
+		if include == base {
+			return []string{chosen}, nil
+		}
+	}
+
+	return []string{base, chosen}, nil
+}
+
+// Plan returns the plan to create a virtual environment and install the requirements
+// from each of 'files' into it
+func Plan(files ...string) project.Plan {
+	steps := []project.Step{
+		{Description: "create virtual environment", Run: python.CreateVenv, Phase: project.Build},
+		{Description: "update seed packages", Run: python.UpdateSeeds},
+	}
+	for _, file := range files {
+		file := file
+		steps = append(steps, project.Step{
+			Description: fmt.Sprintf("install requirements from %s", file),
+			Run: func(cwd string, stdout, stderr io.Writer) error {
+				return python.InstallRequirements(cwd, stdout, stderr, file)
+			},
+		})
+	}
+
+	quoted := make([]string, 0, len(files))
+	for _, file := range files {
+		quoted = append(quoted, fmt.Sprintf("%q", file))
+	}
+
+	return project.Plan{
+		Summary: fmt.Sprintf("Found %s. Creating virtual environment and installing requirements", strings.Join(quoted, ", ")),
+		Steps:   steps,
+	}
+}
@@ -0,0 +1,106 @@
+package requirements
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/FollowTheProcess/venv/pkg/project"
+	"github.com/spf13/afero"
+)
+
+func TestDetector_Detect(t *testing.T) {
+	tests := []struct {
+		files      map[string]string
+		name       string
+		patterns   []string
+		want       []string
+		confidence project.Confidence
+	}{
+		{
+			name:       "no requirements",
+			files:      map[string]string{"pyproject.toml": ""},
+			want:       nil,
+			confidence: project.None,
+		},
+		{
+			name:       "requirements.txt",
+			files:      map[string]string{"requirements.txt": ""},
+			want:       []string{"requirements.txt"},
+			confidence: project.High,
+		},
+		{
+			name:       "requirements-dev.txt",
+			files:      map[string]string{"requirements-dev.txt": ""},
+			want:       []string{"requirements-dev.txt"},
+			confidence: project.High,
+		},
+		{
+			name:       "dev preferred",
+			files:      map[string]string{"requirements.txt": "", "requirements-dev.txt": "-r requirements.txt\npytest\n"},
+			want:       []string{"requirements-dev.txt"},
+			confidence: project.High,
+		},
+		{
+			name:       "dev without the base requirements",
+			files:      map[string]string{"requirements.txt": "", "requirements-dev.txt": "pytest\n"},
+			want:       []string{"requirements.txt", "requirements-dev.txt"},
+			confidence: project.High,
+		},
+		{
+			name:       "base preferred over docs",
+			files:      map[string]string{"requirements.txt": "", "requirements-docs.txt": "mkdocs\n"},
+			want:       []string{"requirements.txt"},
+			confidence: project.High,
+		},
+		{
+			name:       "requirements directory",
+			files:      map[string]string{"requirements/base.txt": "", "requirements/dev.txt": "-r base.txt\n"},
+			want:       []string{"requirements/dev.txt"},
+			confidence: project.High,
+		},
+		{
+			name:       "user patterns install everything they match",
+			files:      map[string]string{"requirements.txt": "", "deps/ci.txt": "", "deps/docs.txt": ""},
+			patterns:   []string{"deps/*.txt"},
+			want:       []string{"deps/docs.txt", "deps/ci.txt"},
+			confidence: project.High,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			af := afero.Afero{Fs: afero.NewMemMapFs()}
+			for file, content := range tt.files {
+				if err := af.WriteFile(file, []byte(content), 0o755); err != nil {
+					t.Fatalf("could not create file: %v", err)
+				}
+			}
+
+			got, err := Detector{Patterns: tt.patterns}.Detect(af, nil)
+			if err != nil {
+				t.Fatalf("Detect() returned an error: %v", err)
+			}
+
+			if got.Confidence != tt.confidence {
+				t.Errorf("got confidence %v, wanted %v", got.Confidence, tt.confidence)
+			}
+
+			// First two steps are always creating the env and updating seeds
+			var installs []string
+			for i, step := range got.Plan.Steps {
+				if i >= 2 {
+					installs = append(installs, step.Description)
+				}
+			}
+
+			var want []string
+			for _, file := range tt.want {
+				want = append(want, "install requirements from "+file)
+			}
+
+			if !reflect.DeepEqual(installs, want) {
+				t.Errorf("got steps %v, wanted %v", installs, want)
+			}
+		})
+	}
+}
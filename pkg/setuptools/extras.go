@@ -0,0 +1,142 @@
+package setuptools
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/FollowTheProcess/venv/pkg/pyproject"
+	"github.com/spf13/afero"
+)
+
+// extrasSection is the setup.cfg section declaring a project's extras
+const extrasSection = "options.extras_require"
+
+// devExtras are the names of extras commonly used to hold development dependencies,
+// if a project declares any of these they are installed by default
+var devExtras = []string{
+	"dev",
+	"develop",
+	"development",
+	"test",
+	"tests",
+	"testing",
+	"lint",
+	"linting",
+	"typing",
+	"docs",
+	"doc",
+}
+
+// Extras returns the names of every extra the project declares, either under
+// [project.optional-dependencies] in it's parsed pyproject.toml 'py' or
+// [options.extras_require] in setup.cfg. Files that do not exist are skipped
+func Extras(fs afero.Afero, py *pyproject.PyProject) ([]string, error) {
+	seen := make(map[string]bool)
+
+	fromToml := py.Extras()
+
+	fromCfg, err := setupCFGExtras(fs)
+	if err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	extras := []string{}
+	for _, extra := range append(fromToml, fromCfg...) {
+		if !seen[extra] {
+			seen[extra] = true
+			extras = append(extras, extra)
+		}
+	}
+	sort.Strings(extras)
+
+	return extras, nil
+}
+
+// ChooseExtras decides which extras to install, if the user has requested
+// specific extras those are always used, otherwise it's whichever of the
+// common development extras the project actually declares
+func ChooseExtras(available, requested []string) []string {
+	if len(requested) != 0 {
+		return requested
+	}
+
+	declared := make(map[string]bool, len(available))
+	for _, extra := range available {
+		declared[extra] = true
+	}
+
+	chosen := []string{}
+	for _, extra := range devExtras {
+		if declared[extra] {
+			chosen = append(chosen, extra)
+		}
+	}
+
+	return chosen
+}
+
+// EditableArgs returns the pip install arguments for an editable install
+// of the project with 'extras'
+func EditableArgs(extras []string) []string {
+	if len(extras) == 0 {
+		return []string{"-e", "."}
+	}
+	return []string{"-e", fmt.Sprintf(".[%s]", strings.Join(extras, ","))}
+}
+
+// setupCFGExtras returns the keys of [options.extras_require] in setup.cfg
+func setupCFGExtras(fs afero.Afero) ([]string, error) {
+	exists, err := fs.Exists(setupCFG)
+	if err != nil {
+		return nil, fmt.Errorf("could not check for %s: %w", setupCFG, err)
+	}
+	if !exists {
+		return nil, nil
+	}
+
+	data, err := fs.ReadFile(setupCFG)
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %w", setupCFG, err)
+	}
+
+	return cfgKeys(data, extrasSection), nil
+}
+
+// cfgKeys returns the keys declared in 'section' of the ini style config in 'data'
+//
+// Only as much of the format as setuptools uses is understood: values may continue
+// over indented lines and both '=' and ':' are valid delimiters
+func cfgKeys(data []byte, section string) []string {
+	var keys []string
+	inSection := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "", strings.HasPrefix(trimmed, "#"), strings.HasPrefix(trimmed, ";"):
+			// Blank or comment
+			continue
+
+		case strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]"):
+			name := strings.TrimSpace(trimmed[1 : len(trimmed)-1])
+			inSection = name == section
+
+		case !inSection, line[0] == ' ', line[0] == '\t':
+			// Another section, or the continuation of a multi-line value
+			continue
+
+		default:
+			if i := strings.IndexAny(trimmed, "=:"); i > 0 {
+				keys = append(keys, strings.TrimSpace(trimmed[:i]))
+			}
+		}
+	}
+
+	return keys
+}
@@ -0,0 +1,119 @@
+package setuptools
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/FollowTheProcess/venv/pkg/pyproject"
+	"github.com/spf13/afero"
+)
+
+func TestExtras(t *testing.T) {
+	af := afero.Afero{Fs: afero.NewMemMapFs()}
+
+	pyProject := `[project]
+name = "demo"
+
+[project.optional-dependencies]
+test = ["pytest"]
+cli = ["click"]
+`
+	setupcfg := `[metadata]
+name = demo
+
+[options.extras_require]
+docs =
+    sphinx
+    furo
+test = pytest
+lint: ruff
+
+[options.entry_points]
+console_scripts =
+    demo = demo.cli:main
+`
+	if err := af.WriteFile("pyproject.toml", []byte(pyProject), 0o755); err != nil {
+		t.Fatalf("could not create file: %v", err)
+	}
+	if err := af.WriteFile("setup.cfg", []byte(setupcfg), 0o755); err != nil {
+		t.Fatalf("could not create file: %v", err)
+	}
+
+	py, err := pyproject.Read(af)
+	if err != nil {
+		t.Fatalf("could not read pyproject.toml: %v", err)
+	}
+
+	got, err := Extras(af, py)
+	if err != nil {
+		t.Fatalf("Extras returned an error: %v", err)
+	}
+
+	want := []string{"cli", "docs", "lint", "test"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, wanted %v", got, want)
+	}
+}
+
+func TestChooseExtras(t *testing.T) {
+	tests := []struct {
+		name      string
+		available []string
+		requested []string
+		want      []string
+	}{
+		{
+			name:      "nothing declared",
+			available: nil,
+			requested: nil,
+			want:      []string{},
+		},
+		{
+			name:      "only dev extras chosen",
+			available: []string{"cli", "docs", "test"},
+			requested: nil,
+			want:      []string{"test", "docs"},
+		},
+		{
+			name:      "requested always wins",
+			available: []string{"dev", "cli"},
+			requested: []string{"cli"},
+			want:      []string{"cli"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ChooseExtras(tt.available, tt.requested); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, wanted %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEditableArgs(t *testing.T) {
+	tests := []struct {
+		name   string
+		extras []string
+		want   []string
+	}{
+		{
+			name:   "no extras",
+			extras: nil,
+			want:   []string{"-e", "."},
+		},
+		{
+			name:   "extras",
+			extras: []string{"dev", "test"},
+			want:   []string{"-e", ".[dev,test]"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EditableArgs(tt.extras); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, wanted %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+// Package setuptools implements detection of setuptools based projects
+package setuptools
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/FollowTheProcess/venv/pkg/project"
+	"github.com/FollowTheProcess/venv/pkg/pyproject"
+	"github.com/FollowTheProcess/venv/pkg/python"
+	"github.com/spf13/afero"
+)
+
+const (
+	setupCFG = "setup.cfg"
+	setupPy  = "setup.py"
+)
+
+// Detector recognises projects with a setup.cfg or a setup.py, either alongside a
+// pyproject.toml or on their own as in legacy projects
+type Detector struct {
+	Extras []string // Extras requested by the user, if empty the common development extras are used
+}
+
+// Name implements project.Detector
+func (Detector) Name() string {
+	return "setuptools"
+}
+
+// Detect implements project.Detector
+func (d Detector) Detect(fs afero.Afero, py *pyproject.PyProject) (project.Match, error) {
+	var setupFile string
+	for _, file := range []string{setupCFG, setupPy} {
+		exists, err := fs.Exists(file)
+		if err != nil {
+			return project.Match{}, fmt.Errorf("could not check for %s: %w", file, err)
+		}
+		if exists {
+			setupFile = file
+			break
+		}
+	}
+	if setupFile == "" {
+		return project.Match{}, nil
+	}
+
+	available, err := Extras(fs, py)
+	if err != nil {
+		return project.Match{}, fmt.Errorf("%w", err)
+	}
+	extras := ChooseExtras(available, d.Extras)
+
+	hasPyProject := py != nil
+
+	match := project.Match{
+		Confidence: project.High,
+		Reasons:    []string{fmt.Sprintf("found %s", setupFile)},
+	}
+
+	summary := fmt.Sprintf("Found %q with %q. Creating virtual environment and installing dependencies (setuptools)", pyproject.File, setupFile)
+	if hasPyProject {
+		match.Reasons = append(match.Reasons, fmt.Sprintf("found %s", pyproject.File))
+	} else {
+		// Legacy project, less certain as a lone setup.py is sometimes just a script
+		// so let anything more specific win
+		match.Confidence = project.Medium
+		match.Reasons = append(match.Reasons, fmt.Sprintf("no %s, treating as a legacy setuptools project", pyproject.File))
+		match.Warnings = append(match.Warnings, fmt.Sprintf("Project has a %s but no %s, consider adding one (see PEP 518)", setupFile, pyproject.File))
+		summary = fmt.Sprintf("Found %q. Creating virtual environment and installing dependencies (setuptools)", setupFile)
+	}
+
+	if len(available) != 0 {
+		match.Reasons = append(match.Reasons, fmt.Sprintf("project declares extras %v", available))
+	}
+
+	match.Plan = plan(summary, EditableArgs(extras))
+
+	return match, nil
+}
+
+// plan returns the plan to create a virtual environment and pip install the project
+// into it with 'installArgs'
+func plan(summary string, installArgs []string) project.Plan {
+	return project.Plan{
+		Summary: summary,
+		Steps: []project.Step{
+			{Description: "create virtual environment", Run: python.CreateVenv, Phase: project.Build},
+			{Description: "update seed packages", Run: python.UpdateSeeds},
+			{
+				Description: fmt.Sprintf("pip install %v", installArgs),
+				Run: func(cwd string, stdout, stderr io.Writer) error {
+					return python.Install(cwd, stdout, stderr, installArgs)
+				},
+			},
+		},
+	}
+}
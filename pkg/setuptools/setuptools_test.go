@@ -0,0 +1,120 @@
+package setuptools
+
+import (
+	"testing"
+
+	"github.com/FollowTheProcess/venv/pkg/project"
+	"github.com/FollowTheProcess/venv/pkg/pyproject"
+	"github.com/spf13/afero"
+)
+
+func TestDetector_Detect(t *testing.T) {
+	tests := []struct {
+		name       string
+		files      map[string]string
+		extras     []string
+		install    string
+		warnings   int
+		confidence project.Confidence
+	}{
+		{
+			name:       "nothing",
+			files:      map[string]string{},
+			confidence: project.None,
+		},
+		{
+			name:       "pyproject.toml on it's own",
+			files:      map[string]string{"pyproject.toml": ""},
+			confidence: project.None,
+		},
+		{
+			name:       "pyproject.toml and setup.cfg",
+			files:      map[string]string{"pyproject.toml": "", "setup.cfg": ""},
+			install:    "pip install [-e .]",
+			confidence: project.High,
+		},
+		{
+			name: "setup.cfg with dev extras",
+			files: map[string]string{
+				"pyproject.toml": "",
+				"setup.cfg":      "[options.extras_require]\ntest = pytest\ncli = click\n",
+			},
+			install:    "pip install [-e .[test]]",
+			confidence: project.High,
+		},
+		{
+			name:       "pyproject.toml and setup.py",
+			files:      map[string]string{"pyproject.toml": "", "setup.py": ""},
+			install:    "pip install [-e .]",
+			confidence: project.High,
+		},
+		{
+			name: "pyproject.toml optional dependencies with setup.py",
+			files: map[string]string{
+				"pyproject.toml": "[project.optional-dependencies]\ndev = [\"pytest\"]\n",
+				"setup.py":       "",
+			},
+			install:    "pip install [-e .[dev]]",
+			confidence: project.High,
+		},
+		{
+			name:       "legacy setup.py",
+			files:      map[string]string{"setup.py": ""},
+			install:    "pip install [-e .]",
+			warnings:   1,
+			confidence: project.Medium,
+		},
+		{
+			name:       "legacy setup.cfg with extras",
+			files:      map[string]string{"setup.cfg": "[options.extras_require]\ndev = pytest\n", "setup.py": ""},
+			install:    "pip install [-e .[dev]]",
+			warnings:   1,
+			confidence: project.Medium,
+		},
+		{
+			name:       "requested extras",
+			files:      map[string]string{"pyproject.toml": "", "setup.cfg": ""},
+			extras:     []string{"cli"},
+			install:    "pip install [-e .[cli]]",
+			confidence: project.High,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			af := afero.Afero{Fs: afero.NewMemMapFs()}
+			for file, content := range tt.files {
+				if err := af.WriteFile(file, []byte(content), 0o755); err != nil {
+					t.Fatalf("could not create file: %v", err)
+				}
+			}
+
+			py, err := pyproject.Read(af)
+			if err != nil {
+				t.Fatalf("could not read pyproject.toml: %v", err)
+			}
+
+			got, err := Detector{Extras: tt.extras}.Detect(af, py)
+			if err != nil {
+				t.Fatalf("Detect() returned an error: %v", err)
+			}
+
+			if got.Confidence != tt.confidence {
+				t.Errorf("got confidence %v, wanted %v", got.Confidence, tt.confidence)
+			}
+
+			if len(got.Warnings) != tt.warnings {
+				t.Errorf("got warnings %v, wanted %d", got.Warnings, tt.warnings)
+			}
+
+			if tt.install == "" {
+				return
+			}
+
+			steps := got.Plan.Steps
+			if last := steps[len(steps)-1].Description; last != tt.install {
+				t.Errorf("got step %q, wanted %q", last, tt.install)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+// Package stamp fingerprints everything that drives the install of a project's
+// environment, so venv can tell whether an existing environment is up to date
+//
+// The fingerprint is stored as a stamp file inside the environment after every
+// successful install and compared against a fresh one on subsequent runs
+package stamp
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/FollowTheProcess/venv/pkg/piptools"
+	"github.com/FollowTheProcess/venv/pkg/requirements"
+	"github.com/spf13/afero"
+)
+
+// File is the name of the stamp file written into the root of an environment
+const File = "venv-stamp.json"
+
+// projectFiles are the files in the project root that, if present, drive the install
+var projectFiles = []string{
+	"pyproject.toml",
+	"setup.cfg",
+	"setup.py",
+	"poetry.lock",
+	"pdm.lock",
+	"uv.lock",
+	"Pipfile",
+	"Pipfile.lock",
+	"environment.yml",
+	"environment.yaml",
+}
+
+// Stamp is the fingerprint of an environment's inputs
+type Stamp struct {
+	Files  map[string]string `json:"files"`  // Path of each input file to the sha256 of it's contents
+	Python string            `json:"python"` // The environment's python version
+}
+
+// Compute fingerprints the project at the root of 'fsys' for an environment
+// running 'python', 'patterns' are the user's extra requirements file patterns
+func Compute(fsys afero.Afero, python string, patterns []string) (Stamp, error) {
+	files, err := Inputs(fsys, patterns)
+	if err != nil {
+		return Stamp{}, fmt.Errorf("%w", err)
+	}
+
+	stamp := Stamp{Python: python, Files: make(map[string]string, len(files))}
+	for _, file := range files {
+		data, err := fsys.ReadFile(file)
+		if err != nil {
+			return Stamp{}, fmt.Errorf("could not read %s: %w", file, err)
+		}
+		sum := sha256.Sum256(data)
+		stamp.Files[file] = hex.EncodeToString(sum[:])
+	}
+
+	return stamp, nil
+}
+
+// Inputs returns every file in the project that drives the install, including
+// requirements files pulled in with -r or -c from another, sorted by path
+func Inputs(fsys afero.Afero, patterns []string) ([]string, error) {
+	seen := make(map[string]bool)
+
+	for _, file := range projectFiles {
+		exists, err := fsys.Exists(file)
+		if err != nil {
+			return nil, fmt.Errorf("could not check for %s: %w", file, err)
+		}
+		if exists {
+			seen[file] = true
+		}
+	}
+
+	reqs, err := requirements.Discover(fsys, patterns)
+	if err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	sources, err := piptools.Discover(fsys)
+	if err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+	for _, source := range sources {
+		reqs = append(reqs, source.In)
+	}
+
+	for _, file := range reqs {
+		included, err := requirements.Includes(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("%w", err)
+		}
+		for _, include := range included {
+			seen[include] = true
+		}
+	}
+
+	files := make([]string, 0, len(seen))
+	for file := range seen {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	return files, nil
+}
+
+// Read reads the stamp from the environment in directory 'env', ok is false
+// if the environment has no stamp
+func Read(fsys afero.Afero, env string) (stamp Stamp, ok bool, err error) {
+	file := path.Join(env, File)
+	exists, err := fsys.Exists(file)
+	if err != nil {
+		return Stamp{}, false, fmt.Errorf("could not check for %s: %w", file, err)
+	}
+	if !exists {
+		return Stamp{}, false, nil
+	}
+
+	data, err := fsys.ReadFile(file)
+	if err != nil {
+		return Stamp{}, false, fmt.Errorf("could not read %s: %w", file, err)
+	}
+
+	if err := json.Unmarshal(data, &stamp); err != nil {
+		return Stamp{}, false, fmt.Errorf("could not parse %s: %w", file, err)
+	}
+
+	return stamp, true, nil
+}
+
+// Write writes 'stamp' into the environment in directory 'env'
+func Write(fsys afero.Afero, env string, stamp Stamp) error {
+	data, err := json.MarshalIndent(stamp, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not encode stamp: %w", err)
+	}
+
+	file := path.Join(env, File)
+	if err := fsys.WriteFile(file, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("could not write %s: %w", file, err)
+	}
+
+	return nil
+}
+
+// Changes returns a description of everything that differs between the stamp
+// an environment was installed with ('s') and the project as it is now ('now'),
+// an empty slice means the environment is up to date
+func (s Stamp) Changes(now Stamp) []string {
+	var changes []string
+	if s.Python != now.Python {
+		changes = append(changes, fmt.Sprintf("python version changed from %q to %q", s.Python, now.Python))
+	}
+
+	files := make([]string, 0, len(s.Files)+len(now.Files))
+	for file := range s.Files {
+		files = append(files, file)
+	}
+	for file := range now.Files {
+		if _, ok := s.Files[file]; !ok {
+			files = append(files, file)
+		}
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		before, was := s.Files[file]
+		after, is := now.Files[file]
+		switch {
+		case !was:
+			changes = append(changes, fmt.Sprintf("%s was added", file))
+		case !is:
+			changes = append(changes, fmt.Sprintf("%s was removed", file))
+		case before != after:
+			changes = append(changes, fmt.Sprintf("%s changed", file))
+		}
+	}
+
+	return changes
+}
@@ -0,0 +1,128 @@
+package stamp
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func newProject(t *testing.T, files map[string]string) afero.Afero {
+	t.Helper()
+	af := afero.Afero{Fs: afero.NewMemMapFs()}
+	for file, content := range files {
+		if err := af.WriteFile(file, []byte(content), 0o755); err != nil {
+			t.Fatalf("could not create file: %v", err)
+		}
+	}
+
+	return af
+}
+
+func TestInputs(t *testing.T) {
+	af := newProject(t, map[string]string{
+		"pyproject.toml":          "",
+		"poetry.lock":             "",
+		"requirements-dev.txt":    "-r requirements.txt\n--constraint=constraints/pins.txt\npytest\n",
+		"requirements.txt":        "-rshared/base.txt\nrequests\n",
+		"shared/base.txt":         "-r ../requirements.txt\n-r https://example.com/reqs.txt\n",
+		"constraints/pins.txt":    "requests==2.28.1\n",
+		"requirements/docs.in":    "-c ../constraints/pins.txt\nmkdocs\n",
+		"deps/extra.txt":          "",
+		"src/demo/__init__.py":    "",
+		"unrelated/notes.txt":     "",
+		"requirements-prod.txt":   "--requirement missing.txt\n",
+		"requirements-typing.txt": "--require-hashes\n",
+	})
+
+	got, err := Inputs(af, []string{"deps/*.txt"})
+	if err != nil {
+		t.Fatalf("Inputs returned an error: %v", err)
+	}
+
+	want := []string{
+		"constraints/pins.txt",
+		"deps/extra.txt",
+		"poetry.lock",
+		"pyproject.toml",
+		"requirements-dev.txt",
+		"requirements-prod.txt",
+		"requirements-typing.txt",
+		"requirements.txt",
+		"requirements/docs.in",
+		"shared/base.txt",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, wanted %v", got, want)
+	}
+}
+
+func TestStamp_Changes(t *testing.T) {
+	files := map[string]string{
+		"pyproject.toml":   "[project]\nname = \"demo\"\n",
+		"requirements.txt": "requests\n",
+	}
+
+	before, err := Compute(newProject(t, files), "3.10.4", nil)
+	if err != nil {
+		t.Fatalf("Compute returned an error: %v", err)
+	}
+
+	t.Run("unchanged", func(t *testing.T) {
+		now, err := Compute(newProject(t, files), "3.10.4", nil)
+		if err != nil {
+			t.Fatalf("Compute returned an error: %v", err)
+		}
+		if changes := before.Changes(now); len(changes) != 0 {
+			t.Errorf("expected no changes, got %v", changes)
+		}
+	})
+
+	t.Run("changed", func(t *testing.T) {
+		now, err := Compute(newProject(t, map[string]string{
+			"requirements.txt": "requests\nrich\n",
+			"setup.cfg":        "",
+		}), "3.11.0", nil)
+		if err != nil {
+			t.Fatalf("Compute returned an error: %v", err)
+		}
+
+		want := []string{
+			`python version changed from "3.10.4" to "3.11.0"`,
+			"pyproject.toml was removed",
+			"requirements.txt changed",
+			"setup.cfg was added",
+		}
+		if got := before.Changes(now); !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, wanted %v", got, want)
+		}
+	})
+}
+
+func TestReadWrite(t *testing.T) {
+	af := newProject(t, map[string]string{".venv/pyvenv.cfg": ""})
+
+	_, ok, err := Read(af, ".venv")
+	if err != nil {
+		t.Fatalf("Read returned an error: %v", err)
+	}
+	if ok {
+		t.Fatal("Read found a stamp that hasn't been written")
+	}
+
+	want := Stamp{Python: "3.10.4", Files: map[string]string{"requirements.txt": "abc123"}}
+	if err := Write(af, ".venv", want); err != nil {
+		t.Fatalf("Write returned an error: %v", err)
+	}
+
+	got, ok, err := Read(af, ".venv")
+	if err != nil {
+		t.Fatalf("Read returned an error: %v", err)
+	}
+	if !ok {
+		t.Fatal("Read did not find the stamp")
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, wanted %#v", got, want)
+	}
+}
@@ -0,0 +1,130 @@
+// Package tools installs the command line tools venv hands projects off to
+// (e.g. poetry) when they aren't already installed, each into an isolated
+// virtual environment of it's own managed by venv
+//
+// The tools' executables are linked into a single bin directory which Activate
+// adds to the end of $PATH, so anything the user has installed themselves always wins
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// pythonCommand, lookPath and userHomeDir are internal reassignments
+// used for mocking during tests
+var (
+	pythonCommand = exec.Command
+	lookPath      = exec.LookPath
+	userHomeDir   = os.UserHomeDir
+)
+
+// dataHomeEnv is the XDG variable for where user specific data files live
+const dataHomeEnv = "XDG_DATA_HOME"
+
+// Dir returns the directory venv installs tools into, "$XDG_DATA_HOME/venv/tools"
+// falling back to "~/.local/share/venv/tools" if $XDG_DATA_HOME is not set
+func Dir() (string, error) {
+	if dataHome := os.Getenv(dataHomeEnv); dataHome != "" {
+		return filepath.Join(dataHome, "venv", "tools"), nil
+	}
+
+	home, err := userHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("could not get user home directory: %w", err)
+	}
+
+	return filepath.Join(home, ".local", "share", "venv", "tools"), nil
+}
+
+// Missing returns those of the tools 'names' that cannot be found on $PATH
+func Missing(names []string) []string {
+	var missing []string
+	for _, name := range names {
+		if _, err := lookPath(name); err != nil {
+			missing = append(missing, name)
+		}
+	}
+
+	return missing
+}
+
+// Install installs the tool 'name' from PyPI into it's own virtual environment
+// under Dir, links it's executable into the tools bin directory and activates it
+// so it can be used straight away
+//
+// The wrapped external commands will be hooked up directly to stdout and stderr
+func Install(name string, stdout, stderr io.Writer) error {
+	dir, err := Dir()
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	env := filepath.Join(dir, name)
+	steps := [][]string{
+		{"python", "-m", "venv", env},
+		{filepath.Join(env, "bin", "python"), "-m", "pip", "install", name},
+	}
+	for _, step := range steps {
+		cmd := pythonCommand(step[0], step[1:]...)
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("could not install %s: %w", name, err)
+		}
+	}
+
+	bin := filepath.Join(dir, "bin")
+	if err := os.MkdirAll(bin, 0o755); err != nil {
+		return fmt.Errorf("could not create %s: %w", bin, err)
+	}
+
+	link := filepath.Join(bin, name)
+	if err := os.Remove(link); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("could not replace %s: %w", link, err)
+	}
+	if err := os.Symlink(filepath.Join(env, "bin", name), link); err != nil {
+		return fmt.Errorf("could not link %s onto $PATH: %w", name, err)
+	}
+
+	return Activate()
+}
+
+// Activate appends the tools bin directory to $PATH for this process and
+// anything it runs, if venv has installed any tools
+func Activate() error {
+	dir, err := Dir()
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	bin := filepath.Join(dir, "bin")
+	if _, err := os.Stat(bin); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("could not check for %s: %w", bin, err)
+	}
+
+	path := os.Getenv("PATH")
+	for _, entry := range filepath.SplitList(path) {
+		if entry == bin {
+			return nil
+		}
+	}
+
+	if path != "" {
+		bin = strings.Join([]string{path, bin}, string(os.PathListSeparator))
+	}
+	if err := os.Setenv("PATH", bin); err != nil {
+		return fmt.Errorf("could not add venv's tools to $PATH: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,193 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testCase is used as an env var to pass around so our test helper
+// knows what condition to test for
+var testCase string
+
+// extractCmdArgs is a helper for TestHelperProcess which teases out the desired
+// external command arguments from the special ones required to make go test use the
+// helper process
+func extractCmdArgs(args []string) []string {
+	for len(args) > 0 {
+		if args[0] == "--" {
+			args = args[1:]
+			break
+		}
+		args = args[1:]
+	}
+	return args
+}
+
+// fakeExecCommand is a helper that creates a fake external command
+// see: https://npf.io/2015/06/testing-exec-command/
+func fakeExecCommand(command string, args ...string) *exec.Cmd {
+	cs := []string{"-test.run=TestToolsHelperProcess", "--", command}
+	cs = append(cs, args...)
+
+	cmd := exec.Command(os.Args[0], cs...)
+	tc := "TOOLS_TEST_CASE=" + testCase
+	cmd.Env = []string{"GO_WANT_TOOLS_HELPER_PROCESS=1", tc}
+	return cmd
+}
+
+func setUp(testcase string) {
+	pythonCommand = fakeExecCommand
+	testCase = testcase
+}
+
+func tearDown() {
+	pythonCommand = exec.Command
+}
+
+// TestToolsHelperProcess is the helper process for external command tests, each
+// invocation of the fake command checks it was called with one of the expected commands
+func TestToolsHelperProcess(t *testing.T) {
+	if os.Getenv("GO_WANT_TOOLS_HELPER_PROCESS") != "1" {
+		return
+	}
+
+	args := extractCmdArgs(os.Args)
+
+	switch os.Getenv("TOOLS_TEST_CASE") {
+	case "install_success":
+		switch {
+		case reflect.DeepEqual(args[:3], []string{"python", "-m", "venv"}):
+		case strings.HasSuffix(args[0], filepath.Join("poetry", "bin", "python")) &&
+			reflect.DeepEqual(args[1:], []string{"-m", "pip", "install", "poetry"}):
+		default:
+			fmt.Fprintf(os.Stderr, "Error: unexpected cmd %#v", args)
+			os.Exit(1)
+		}
+
+	case "install_error":
+		fmt.Fprintf(os.Stderr, "something wrong")
+		os.Exit(1)
+	}
+}
+
+func TestDir(t *testing.T) {
+	t.Run("XDG_DATA_HOME", func(t *testing.T) {
+		t.Setenv(dataHomeEnv, "/data")
+
+		got, err := Dir()
+		if err != nil {
+			t.Fatalf("Dir returned an error: %v", err)
+		}
+
+		if want := filepath.Join("/data", "venv", "tools"); got != want {
+			t.Errorf("got %q, wanted %q", got, want)
+		}
+	})
+
+	t.Run("home", func(t *testing.T) {
+		t.Setenv(dataHomeEnv, "")
+		userHomeDir = func() (string, error) { return "/home/me", nil }
+		defer func() { userHomeDir = os.UserHomeDir }()
+
+		got, err := Dir()
+		if err != nil {
+			t.Fatalf("Dir returned an error: %v", err)
+		}
+
+		if want := filepath.Join("/home/me", ".local", "share", "venv", "tools"); got != want {
+			t.Errorf("got %q, wanted %q", got, want)
+		}
+	})
+}
+
+func TestMissing(t *testing.T) {
+	lookPath = func(file string) (string, error) {
+		if file == "poetry" {
+			return "/usr/bin/poetry", nil
+		}
+		return "", exec.ErrNotFound
+	}
+	defer func() { lookPath = exec.LookPath }()
+
+	got := Missing([]string{"poetry", "pdm", "uv"})
+	if want := []string{"pdm", "uv"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, wanted %v", got, want)
+	}
+}
+
+func TestInstall(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		data := t.TempDir()
+		t.Setenv(dataHomeEnv, data)
+		t.Setenv("PATH", "/usr/bin")
+		setUp("install_success")
+		defer tearDown()
+
+		if err := Install("poetry", os.Stdout, os.Stderr); err != nil {
+			t.Fatalf("Install returned an error: %v", err)
+		}
+
+		bin := filepath.Join(data, "venv", "tools", "bin")
+		target, err := os.Readlink(filepath.Join(bin, "poetry"))
+		if err != nil {
+			t.Fatalf("poetry not linked into the tools bin directory: %v", err)
+		}
+		if want := filepath.Join(data, "venv", "tools", "poetry", "bin", "poetry"); target != want {
+			t.Errorf("got link to %q, wanted %q", target, want)
+		}
+
+		if want := "/usr/bin" + string(os.PathListSeparator) + bin; os.Getenv("PATH") != want {
+			t.Errorf("got $PATH %q, wanted %q", os.Getenv("PATH"), want)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		t.Setenv(dataHomeEnv, t.TempDir())
+		setUp("install_error")
+		defer tearDown()
+
+		if err := Install("poetry", os.Stdout, os.Stderr); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestActivate(t *testing.T) {
+	t.Run("nothing installed", func(t *testing.T) {
+		t.Setenv(dataHomeEnv, t.TempDir())
+		t.Setenv("PATH", "/usr/bin")
+
+		if err := Activate(); err != nil {
+			t.Fatalf("Activate returned an error: %v", err)
+		}
+
+		if got := os.Getenv("PATH"); got != "/usr/bin" {
+			t.Errorf("$PATH changed to %q", got)
+		}
+	})
+
+	t.Run("only added once", func(t *testing.T) {
+		data := t.TempDir()
+		t.Setenv(dataHomeEnv, data)
+		t.Setenv("PATH", "/usr/bin")
+		bin := filepath.Join(data, "venv", "tools", "bin")
+		if err := os.MkdirAll(bin, 0o755); err != nil {
+			t.Fatalf("could not create tools bin: %v", err)
+		}
+
+		for i := 0; i < 2; i++ {
+			if err := Activate(); err != nil {
+				t.Fatalf("Activate returned an error: %v", err)
+			}
+		}
+
+		if want := "/usr/bin" + string(os.PathListSeparator) + bin; os.Getenv("PATH") != want {
+			t.Errorf("got $PATH %q, wanted %q", os.Getenv("PATH"), want)
+		}
+	})
+}
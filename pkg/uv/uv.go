@@ -0,0 +1,66 @@
+// Package uv implements wrapper functions around uv project commands
+package uv
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+
+	"github.com/FollowTheProcess/venv/pkg/project"
+	"github.com/FollowTheProcess/venv/pkg/pyproject"
+	"github.com/spf13/afero"
+)
+
+var uvCommand = exec.Command
+
+const lockFile = "uv.lock"
+
+// newUVCommand returns an exec.Cmd configured with the parameters passed in
+func newUVCommand(cwd string, stdout, stderr io.Writer, args []string) *exec.Cmd {
+	cmd := uvCommand("uv", args...)
+	cmd.Dir = cwd
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	return cmd
+}
+
+// Sync calls uv sync, which creates .venv in the project and installs exactly
+// what is in uv.lock, including the dev dependency group
+func Sync(cwd string, stdout, stderr io.Writer) error {
+	cmd := newUVCommand(cwd, stdout, stderr, []string{"sync"})
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("could not sync uv environment: %w", err)
+	}
+
+	return nil
+}
+
+// Detector recognises projects managed by uv, i.e. those with a uv.lock
+type Detector struct{}
+
+// Name implements project.Detector
+func (Detector) Name() string {
+	return "uv"
+}
+
+// Detect implements project.Detector
+func (Detector) Detect(fs afero.Afero, _ *pyproject.PyProject) (project.Match, error) {
+	exists, err := fs.Exists(lockFile)
+	if err != nil {
+		return project.Match{}, fmt.Errorf("could not check for %s: %w", lockFile, err)
+	}
+	if !exists {
+		return project.Match{}, nil
+	}
+
+	return project.Match{
+		Confidence: project.High,
+		Reasons:    []string{fmt.Sprintf("found %s", lockFile)},
+		Plan: project.Plan{
+			Summary: fmt.Sprintf("Found %q. Syncing environment with uv", lockFile),
+			Steps:   []project.Step{{Description: "uv sync", Run: Sync}},
+			Tools:   []string{"uv"},
+		},
+	}, nil
+}